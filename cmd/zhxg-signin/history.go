@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"zhxg-signin/internal/history"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "查询签到运行记录",
+	Long: `查询签到运行记录，并按账号统计成功率和连续签到成功天数。
+
+--since 支持日期（2025-08-01）、日期时间（2025-08-01 08:00）或相对时长（7d、12h）。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		account, _ := cmd.Flags().GetString("account")
+		since, _ := cmd.Flags().GetString("since")
+		status, _ := cmd.Flags().GetString("status")
+		taskType, _ := cmd.Flags().GetString("task-type")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		// 日期按定时任务的时区解析和显示，与每日汇总一致
+		loc, err := time.LoadLocation(cfg.Scheduler.Timezone)
+		if err != nil {
+			fmt.Printf("无效的时区 %q: %v\n", cfg.Scheduler.Timezone, err)
+			os.Exit(1)
+		}
+
+		q := history.Query{Account: account, TaskType: taskType, Limit: limit}
+		if since != "" {
+			t, err := parseSince(since, time.Now(), loc)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			q.Since = t
+		}
+		switch status {
+		case "":
+		case "success", "failure":
+			ok := status == "success"
+			q.Success = &ok
+		default:
+			fmt.Printf("无效的 --status %q，应为 success 或 failure\n", status)
+			os.Exit(1)
+		}
+
+		hist, err := history.OpenReadOnly(cfg.History)
+		if err != nil {
+			fmt.Printf("打开运行记录失败: %v\n", err)
+			os.Exit(1)
+		}
+		defer hist.Close()
+
+		records, err := hist.List(q)
+		if err != nil {
+			fmt.Printf("查询运行记录失败: %v\n", err)
+			os.Exit(1)
+		}
+		// --status 和 --limit 只过滤列出的记录，统计仍包含范围内的全部运行
+		stats, err := history.Stats(hist, q, loc)
+		if err != nil {
+			fmt.Printf("统计运行记录失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				fmt.Printf("创建输出文件失败: %v\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		switch format {
+		case "table":
+			err = writeHistoryTable(w, records, stats, loc)
+		case "csv":
+			err = writeHistoryCSV(w, records)
+		case "json":
+			err = writeHistoryJSON(w, records, stats)
+		default:
+			err = fmt.Errorf("无效的 --format %q，应为 table、csv 或 json", format)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	historyCmd.Flags().String("account", "", "按账号过滤")
+	historyCmd.Flags().String("since", "", "仅显示该时间之后的记录")
+	historyCmd.Flags().String("status", "", "按结果过滤：success 或 failure")
+	historyCmd.Flags().String("task-type", "", "按签到任务类型过滤，如 实习")
+	historyCmd.Flags().StringP("format", "f", "table", "输出格式：table、csv 或 json")
+	historyCmd.Flags().StringP("output", "o", "", "导出到文件，默认输出到终端")
+	historyCmd.Flags().IntP("limit", "n", 0, "最多显示的记录数，0 表示不限制")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+// parseSince 解析 --since，支持 loc 中的日期、日期时间以及 7d、12h 这样的相对时长
+func parseSince(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的 --since %q", s)
+}
+
+func writeHistoryTable(w io.Writer, records []history.Record, stats []history.AccountStats, loc *time.Location) error {
+	if len(records) == 0 {
+		if _, err := fmt.Fprintln(w, "没有符合条件的运行记录"); err != nil || len(stats) == 0 {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "时间\t账号\t来源\t任务\t阶段\t结果\t错误分类")
+		for _, rec := range records {
+			result := "成功"
+			if !rec.Success {
+				result = "失败"
+			}
+			task := rec.TaskType
+			if task == "" {
+				task = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				rec.StartedAt.In(loc).Format("2006-01-02 15:04:05"), rec.DisplayName(), rec.Schedule, task, rec.Stage, result, rec.ErrorClass)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "账号\t运行次数\t成功次数\t成功率\t连续成功天数\t最近成功")
+	for _, st := range stats {
+		last := "-"
+		if !st.LastSuccess.IsZero() {
+			last = st.LastSuccess.In(loc).Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%d\t%s\n", st.Account, st.Total, st.Succeeded, st.SuccessRate()*100, st.Streak, last)
+	}
+	return tw.Flush()
+}
+
+func writeHistoryCSV(w io.Writer, records []history.Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "account", "name", "schedule", "started_at", "finished_at", "stage", "task_type", "strategy", "signin_id", "batch_no",
+		"task_ids", "batch_nos", "captcha_attempts", "solver", "lng", "lat", "success", "error_class", "error"})
+	for _, rec := range records {
+		cw.Write([]string{
+			strconv.FormatUint(rec.ID, 10),
+			rec.Account,
+			rec.Name,
+			rec.Schedule,
+			rec.StartedAt.Format(time.RFC3339),
+			rec.FinishedAt.Format(time.RFC3339),
+			rec.Stage,
+			rec.TaskType,
+			rec.Strategy,
+			strconv.Itoa(rec.SigninID),
+			strconv.Itoa(rec.BatchNo),
+			joinInts(rec.TaskIDs),
+			joinInts(rec.BatchNos),
+			strconv.Itoa(rec.CaptchaAttempts),
+			rec.Solver,
+			strconv.FormatFloat(rec.Lng, 'f', 6, 64),
+			strconv.FormatFloat(rec.Lat, 'f', 6, 64),
+			strconv.FormatBool(rec.Success),
+			rec.ErrorClass,
+			rec.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// joinInts 以空格连接多个整数，作为 CSV 中的单个字段
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, " ")
+}
+
+func writeHistoryJSON(w io.Writer, records []history.Record, stats []history.AccountStats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Records []history.Record       `json:"records"`
+		Stats   []history.AccountStats `json:"stats"`
+	}{Records: records, Stats: stats})
+}
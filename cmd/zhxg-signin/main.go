@@ -7,15 +7,26 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"zhxg-signin/internal/api"
+	"zhxg-signin/internal/bot"
+	"zhxg-signin/internal/cassette"
 	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/health"
+	"zhxg-signin/internal/history"
 	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/metrics"
+	"zhxg-signin/internal/runner"
 	"zhxg-signin/internal/scheduler"
+	"zhxg-signin/internal/server"
 	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/web"
 )
 
 var (
-	cfgFile string
-	cfg     config.Config
+	cfgFile    string
+	cfg        config.Config
+	daemonMode string
 )
 
 var rootCmd = &cobra.Command{
@@ -51,9 +62,28 @@ var runCmd = &cobra.Command{
 	Short: "执行一次签到任务",
 	Run: func(cmd *cobra.Command, args []string) {
 		logger.GetLogger().Info("开始执行一次性签到任务")
-		service := signin.NewService(cfg)
-		if err := service.Run(); err != nil {
-			logger.GetLogger().Error("签到任务失败", zap.Error(err))
+		if dir, _ := cmd.Flags().GetString("record"); dir != "" {
+			cfg.Cassette = config.CassetteConfig{Mode: cassette.ModeRecord, Dir: dir}
+		}
+		if dir, _ := cmd.Flags().GetString("replay"); dir != "" {
+			cfg.Cassette = config.CassetteConfig{Mode: cassette.ModeReplay, Dir: dir}
+		}
+		store, err := state.Open(cfg.State.File)
+		if err != nil {
+			logger.GetLogger().Error("打开状态文件失败", zap.Error(err))
+			os.Exit(1)
+		}
+		hist, err := history.Open(cfg.History)
+		if err != nil {
+			logger.GetLogger().Error("打开运行记录失败", zap.Error(err))
+			os.Exit(1)
+		}
+		r, err := runner.New(cfg, store, hist)
+		if err != nil {
+			logger.GetLogger().Error("初始化签到执行器失败", zap.Error(err))
+			os.Exit(1)
+		}
+		if res := r.Run("manual"); !res.Success() {
 			os.Exit(1)
 		}
 		logger.GetLogger().Info("签到任务执行完毕")
@@ -64,18 +94,109 @@ var daemonCmd = &cobra.Command{
 	Use:   "daemon",
 	Short: "以守护进程模式运行，执行定时任务",
 	Run: func(cmd *cobra.Command, args []string) {
-		scheduler.StartScheduler(cfg)
+		log := logger.GetLogger()
+		store, err := state.Open(cfg.State.File)
+		if err != nil {
+			log.Error("打开状态文件失败", zap.Error(err))
+			os.Exit(1)
+		}
+		hist, err := history.Open(cfg.History)
+		if err != nil {
+			log.Error("打开运行记录失败", zap.Error(err))
+			os.Exit(1)
+		}
+		// mon 收集调度器心跳和会话检查结果，供 /healthz 和 /readyz 使用
+		mon := health.NewMonitor()
+		r, err := runner.New(cfg, store, hist, signin.WithSessionRecorder(mon))
+		if err != nil {
+			log.Error("初始化签到执行器失败", zap.Error(err))
+			os.Exit(1)
+		}
+
+		// 定时模式下先创建调度器，以便控制接口可以暂停和恢复定时计划
+		var sched *scheduler.Scheduler
+		switch daemonMode {
+		case "cron":
+			switch {
+			case cfg.Scheduler.Enabled:
+				sched, err = scheduler.New(cfg, store, hist, r, mon)
+				if err != nil {
+					log.Error("初始化定时任务失败", zap.Error(err))
+					os.Exit(1)
+				}
+			case cfg.Notify.Telegram.Commands || cfg.Server.Listen != "":
+				// 仍可通过 Telegram 命令或控制接口手动签到
+				log.Info("定时任务未启用，仅运行 Telegram 机器人和 HTTP 服务")
+			default:
+				log.Info("定时任务未启用")
+				return
+			}
+		case "watch":
+		default:
+			log.Error("未知的运行模式", zap.String("mode", daemonMode))
+			os.Exit(1)
+		}
+
+		if cfg.Notify.Telegram.Commands {
+			tg, err := bot.NewTelegram(cfg, store, hist, r)
+			if err != nil {
+				log.Error("初始化 Telegram 机器人失败", zap.Error(err))
+				os.Exit(1)
+			}
+			go tg.Start()
+		}
+
+		if cfg.Server.Listen != "" {
+			srv := server.New(cfg.Server)
+			srv.Handle("GET /metrics", metrics.Handler())
+			srv.Handle("GET /healthz", mon.LivenessHandler())
+			srv.Handle("GET /readyz", health.NewChecker(cfg, mon, r.ValidateFlows))
+			if cfg.Server.APIToken != "" {
+				api.New(cfg, store, hist, r, sched).Register(srv)
+				srv.Handle("GET /", web.Handler())
+			}
+			go func() {
+				// 监听失败时守护进程无法提供健康检查和控制接口，直接退出
+				if err := srv.Start(); err != nil {
+					log.Error("HTTP 服务已退出", zap.Error(err))
+					os.Exit(1)
+				}
+			}()
+
+			// 启动时检查一次保存的会话，使 /readyz 尽早反映账号状态，会话失效时不在此处重新登录
+			go func() {
+				if err := r.ProbeSession(); err != nil {
+					log.Warn("启动时会话检查失败", zap.Error(err))
+				}
+			}()
+		}
+
+		if daemonMode == "watch" {
+			scheduler.StartWatcher(cfg, hist, r, mon)
+			return
+		}
+		if sched != nil {
+			sched.Start()
+		}
+
+		// 阻塞主 goroutine
+		select {}
 	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "./configs", "配置文件路径")
-	
+
 	runCmd.Flags().StringP("username", "u", "", "登录用户名")
 	runCmd.Flags().StringP("password", "p", "", "登录密码")
 	runCmd.Flags().Float64("lng", 0, "经度")
 	runCmd.Flags().Float64("lat", 0, "纬度")
 	runCmd.Flags().StringP("api-key", "k", "", "LLM API Key")
+	runCmd.Flags().String("record", "", "将脱敏后的请求和响应录制到该目录")
+	runCmd.Flags().String("replay", "", "回放该目录中录制的请求和响应，不访问网络")
+	runCmd.MarkFlagsMutuallyExclusive("record", "replay")
+
+	daemonCmd.Flags().StringVarP(&daemonMode, "mode", "m", "cron", "运行模式：cron（按定时计划执行）或 watch（轮询待签到任务）")
 
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(daemonCmd)
@@ -86,4 +207,4 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"zhxg-signin/internal/llmtest"
+	"zhxg-signin/internal/wisestutest"
+)
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "启动模拟的智慧学工服务，用于本地调试",
+	Long: `启动一个模拟的智慧学工服务，实现登录、验证码、学生信息和签到接口。
+将配置中的 signin.base_url 指向该服务即可在不访问学校服务器的情况下调试签到流程。`,
+	// 模拟服务不需要读取配置文件
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+		scenario, _ := cmd.Flags().GetString("scenario")
+		username, _ := cmd.Flags().GetString("username")
+		password, _ := cmd.Flags().GetString("password")
+		llmListen, _ := cmd.Flags().GetString("llm-listen")
+		list, _ := cmd.Flags().GetBool("list")
+
+		if list {
+			for _, sc := range wisestutest.Scenarios() {
+				fmt.Printf("%-16s %s\n", sc[0], sc[1])
+			}
+			return
+		}
+
+		opts, err := wisestutest.Scenario(scenario)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if username != "" {
+			opts.Username = username
+		}
+		// wrong-password 场景依赖于预置的密码
+		if password != "" && scenario != wisestutest.ScenarioWrongPassword {
+			opts.Password = password
+		}
+
+		srv := wisestutest.New(opts)
+		if llmListen != "" {
+			// 模拟的 LLM 服务直接查询验证码答案，无需真实模型即可完成登录
+			llm := llmtest.New(llmtest.Solver(srv.AnswerImage))
+			go func() {
+				if err := http.ListenAndServe(llmListen, llm); err != nil {
+					fmt.Printf("模拟 LLM 服务已退出: %v\n", err)
+					os.Exit(1)
+				}
+			}()
+			fmt.Printf("模拟 LLM 服务已启动，请将 llm.endpoint 设置为 http://%s/v1/chat/completions\n", strings.Replace(llmListen, "0.0.0.0", "127.0.0.1", 1))
+		}
+
+		fmt.Printf("模拟智慧学工服务已启动，场景: %s\n", scenario)
+		fmt.Printf("请将 signin.base_url 设置为 http://%s\n", strings.Replace(listen, "0.0.0.0", "127.0.0.1", 1))
+		if err := http.ListenAndServe(listen, srv); err != nil {
+			fmt.Printf("模拟服务已退出: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	mockServerCmd.Flags().String("listen", "127.0.0.1:18080", "监听地址")
+	mockServerCmd.Flags().StringP("scenario", "s", wisestutest.ScenarioOK, "预置场景，使用 --list 查看全部场景")
+	mockServerCmd.Flags().StringP("username", "u", "", "接受的登录用户名，默认为 20230001")
+	mockServerCmd.Flags().StringP("password", "p", "", "接受的登录密码，默认为 password")
+	mockServerCmd.Flags().String("llm-listen", "", "同时启动模拟的 LLM 服务并监听该地址，如 127.0.0.1:18081")
+	mockServerCmd.Flags().Bool("list", false, "列出所有预置场景")
+
+	rootCmd.AddCommand(mockServerCmd)
+}
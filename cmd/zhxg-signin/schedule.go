@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"zhxg-signin/internal/scheduler"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "查看定时计划",
+}
+
+var scheduleNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "预览接下来的定时签到",
+	Run: func(cmd *cobra.Command, args []string) {
+		n, _ := cmd.Flags().GetInt("count")
+		plans, err := scheduler.Plan(cfg, time.Now(), n)
+		if err != nil {
+			fmt.Printf("计算定时计划失败: %v\n", err)
+			os.Exit(1)
+		}
+		if len(plans) == 0 {
+			fmt.Println("未配置任何定时计划")
+			os.Exit(1)
+		}
+
+		invalid := false
+		for _, plan := range plans {
+			fmt.Printf("账号: %s  计划: %s  cron: %q  窗口: %s\n", plan.Account, plan.Schedule.Name, plan.Schedule.Cron, plan.Schedule.Window)
+			if plan.Err != nil {
+				fmt.Printf("  错误: %v\n\n", plan.Err)
+				invalid = true
+				continue
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, run := range plan.Runs {
+				when := run.Fire.Format("2006-01-02 Mon 15:04")
+				if run.Latest.After(run.Fire) {
+					when += " ~ " + run.Latest.Format("15:04")
+				}
+				if run.Skipped {
+					fmt.Fprintf(w, "  %s\t跳过\t%s\n", when, run.SkipReason)
+				} else {
+					fmt.Fprintf(w, "  %s\t执行\t\n", when)
+				}
+			}
+			w.Flush()
+			fmt.Println()
+		}
+
+		if invalid {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	scheduleNextCmd.Flags().IntP("count", "n", 10, "每个计划显示的执行次数")
+
+	scheduleCmd.AddCommand(scheduleNextCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/wisestu"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "登录并显示当前账号的学生信息",
+	Long:  `登录智慧学工并显示 queryMyStuInfo 返回的学生信息，用于确认配置的账号是否正确。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		store, err := state.Open(cfg.State.File)
+		if err != nil {
+			fmt.Printf("打开状态文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		student, err := signin.NewService(cfg, store).Profile()
+		if err != nil {
+			fmt.Printf("获取学生信息失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON {
+			err = writeStudentJSON(student)
+		} else {
+			err = writeStudentTable(student)
+		}
+		if err != nil {
+			fmt.Printf("输出学生信息失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		if want := cfg.User.RealName; want != "" && student.RealName != want {
+			fmt.Printf("\n警告: 配置的 user.real_name 为 %s，与学生信息不一致，签到时将不会提交\n", want)
+			os.Exit(1)
+		}
+	},
+}
+
+func writeStudentTable(s *wisestu.Student) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "账号\t%s\n", cfg.User.Username)
+	for _, row := range [][2]string{
+		{"姓名", s.RealName},
+		{"学号", s.StuNo},
+		{"性别", s.Gender},
+		{"学院", s.College},
+		{"专业", s.Major},
+		{"班级", s.ClassName},
+		{"年级", s.Grade},
+		{"辅导员", s.Counselor},
+		{"辅导员电话", s.CounselorPhone},
+	} {
+		if row[1] != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+		}
+	}
+
+	keys := make([]string, 0, len(s.Extra))
+	for k := range s.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%v\n", k, s.Extra[k])
+	}
+	return tw.Flush()
+}
+
+// writeStudentJSON 输出学生信息，包括未建模的字段
+func writeStudentJSON(s *wisestu.Student) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	all := make(map[string]interface{}, len(s.Extra))
+	for k, v := range s.Extra {
+		all[k] = v
+	}
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(all)
+}
+
+func init() {
+	whoamiCmd.Flags().Bool("json", false, "以 JSON 格式输出")
+
+	rootCmd.AddCommand(whoamiCmd)
+}
@@ -0,0 +1,395 @@
+package api
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/runner"
+	"zhxg-signin/internal/scheduler"
+	"zhxg-signin/internal/server"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+)
+
+const (
+	defaultHistoryLimit = 50
+	defaultUpcoming     = 5
+	maxUpcoming         = 50
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// API 是守护进程的 REST 控制接口
+type API struct {
+	cfg       config.Config
+	store     *state.Store
+	hist      history.Store
+	runner    *runner.Runner
+	scheduler *scheduler.Scheduler // 轮询模式下为 nil
+	log       *zap.Logger
+}
+
+// New 创建控制接口，sched 为 nil 时定时计划相关接口返回空列表
+func New(cfg config.Config, store *state.Store, hist history.Store, r *runner.Runner, sched *scheduler.Scheduler) *API {
+	return &API{
+		cfg:       cfg,
+		store:     store,
+		hist:      hist,
+		runner:    r,
+		scheduler: sched,
+		log:       logger.GetLogger(),
+	}
+}
+
+// Register 将控制接口挂载到 srv 上，除 OpenAPI 描述外的接口都需要 Bearer token。
+// 修改签到位置和暂停、恢复定时计划只允许管理员 token（server.api_token）调用
+func (a *API) Register(srv *server.Server) {
+	srv.Handle("GET /api/v1/openapi.json", http.HandlerFunc(a.openAPI))
+
+	routes := map[string]http.HandlerFunc{
+		"GET /api/v1/me":                       a.me,
+		"GET /api/v1/accounts":                 a.listAccounts,
+		"POST /api/v1/accounts/{account}/run":  a.runAccount,
+		"GET /api/v1/accounts/{account}/tasks": a.pendingTasks,
+		"GET /api/v1/schedules":                a.listSchedules,
+		"GET /api/v1/upcoming":                 a.upcoming,
+		"GET /api/v1/history":                  a.listHistory,
+	}
+	for pattern, h := range routes {
+		srv.Handle(pattern, a.auth(h, false))
+	}
+
+	adminRoutes := map[string]http.HandlerFunc{
+		"PUT /api/v1/accounts/{account}/location": a.updateLocation,
+		"POST /api/v1/schedules/{name}/pause":     a.pauseSchedule,
+		"POST /api/v1/schedules/{name}/resume":    a.resumeSchedule,
+	}
+	for pattern, h := range adminRoutes {
+		srv.Handle(pattern, a.auth(h, true))
+	}
+}
+
+// role 校验 Authorization 请求头中的 Bearer token，返回是否为管理员以及 token 是否有效
+func (a *API) role(r *http.Request) (admin, ok bool) {
+	got := []byte(r.Header.Get("Authorization"))
+	if subtle.ConstantTimeCompare(got, []byte("Bearer "+a.cfg.Server.APIToken)) == 1 {
+		return true, true
+	}
+	// user_token 为空时不能用空 token 登录
+	if a.cfg.Server.UserToken != "" && subtle.ConstantTimeCompare(got, []byte("Bearer "+a.cfg.Server.UserToken)) == 1 {
+		return false, true
+	}
+	return false, false
+}
+
+// auth 校验 token，adminOnly 为 true 时普通用户的 token 返回 403
+func (a *API) auth(next http.Handler, adminOnly bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := a.role(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="zhxg-signin"`)
+			writeError(w, http.StatusUnauthorized, errors.New("缺少或无效的 API token"))
+			return
+		}
+		if adminOnly && !admin {
+			writeError(w, http.StatusForbidden, errors.New("该操作需要管理员 token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// me 返回当前 token 的权限，供控制台决定是否显示管理操作
+func (a *API) me(w http.ResponseWriter, r *http.Request) {
+	admin, _ := a.role(r)
+	writeJSON(w, http.StatusOK, struct {
+		Admin bool `json:"admin"`
+	}{Admin: admin})
+}
+
+func (a *API) openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(openAPISpec)
+}
+
+// Account 是账号及其最近一次签到的概况
+type Account struct {
+	Account    string          `json:"account"`
+	Name       string          `json:"name,omitempty"` // 最近一次签到时获取的真实姓名
+	LastStatus string          `json:"last_status"`    // success、failure 或 unknown
+	LastRun    *history.Record `json:"last_run,omitempty"`
+	Location   Location        `json:"location"`
+}
+
+// Location 是账号签到使用的位置
+type Location struct {
+	Longitude  float64 `json:"longitude"`
+	Latitude   float64 `json:"latitude"`
+	Overridden bool    `json:"overridden"` // 是否为控制台修改后的位置
+}
+
+// UpcomingRun 是一次即将执行的定时签到
+type UpcomingRun struct {
+	Schedule   string    `json:"schedule"`
+	Fire       time.Time `json:"fire"`
+	Latest     time.Time `json:"latest"`
+	Paused     bool      `json:"paused"`
+	Skipped    bool      `json:"skipped"`
+	SkipReason string    `json:"skip_reason,omitempty"`
+}
+
+func (a *API) listAccounts(w http.ResponseWriter, r *http.Request) {
+	account := a.cfg.User.Username
+	acc := Account{Account: account, LastStatus: "unknown"}
+	if ok, known := a.store.LastStatus(account); known {
+		acc.LastStatus = "failure"
+		if ok {
+			acc.LastStatus = "success"
+		}
+	}
+
+	runs, err := a.hist.List(history.Query{Account: account, Limit: 1})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("查询运行记录失败: %w", err))
+		return
+	}
+	if len(runs) > 0 {
+		acc.LastRun = &runs[0]
+		acc.Name = runs[0].Name
+	}
+	acc.Location = a.location()
+	writeJSON(w, http.StatusOK, []Account{acc})
+}
+
+func (a *API) location() Location {
+	loc := a.runner.Location()
+	_, overridden := a.store.Location(a.cfg.User.Username)
+	return Location{Longitude: loc.Longitude, Latitude: loc.Latitude, Overridden: overridden}
+}
+
+// updateLocation 修改账号的签到位置，修改保存在状态文件中并覆盖配置文件
+func (a *API) updateLocation(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAccount(w, r) {
+		return
+	}
+
+	var loc state.Location
+	if err := json.NewDecoder(r.Body).Decode(&loc); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("解析请求体失败: %w", err))
+		return
+	}
+	if loc.Longitude < -180 || loc.Longitude > 180 || loc.Latitude < -90 || loc.Latitude > 90 ||
+		(loc.Longitude == 0 && loc.Latitude == 0) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的经纬度: %v, %v", loc.Longitude, loc.Latitude))
+		return
+	}
+
+	if err := a.store.SetLocation(a.cfg.User.Username, loc); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("保存签到位置失败: %w", err))
+		return
+	}
+	a.log.Info("已修改签到位置", zap.Float64("lng", loc.Longitude), zap.Float64("lat", loc.Latitude), zap.String("remote", r.RemoteAddr))
+	writeJSON(w, http.StatusOK, a.location())
+}
+
+// runAccount 立即为账号执行一次签到，等待签到完成后返回运行记录
+func (a *API) runAccount(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAccount(w, r) {
+		return
+	}
+	a.log.Info("收到 API 签到请求", zap.String("remote", r.RemoteAddr))
+	res := a.runner.Run("api")
+	writeJSON(w, http.StatusOK, history.FromResult(res))
+}
+
+func (a *API) pendingTasks(w http.ResponseWriter, r *http.Request) {
+	if !a.checkAccount(w, r) {
+		return
+	}
+	tasks, err := a.runner.PendingTasks()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("获取未签到任务失败: %w", err))
+		return
+	}
+	if tasks == nil {
+		tasks = []signin.SigninTask{}
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (a *API) listSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules := []scheduler.ScheduleStatus{}
+	if a.scheduler != nil {
+		schedules = a.scheduler.Schedules()
+	}
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// upcoming 返回各定时计划接下来的执行安排，按时间排序，n 参数指定每个计划的次数
+func (a *API) upcoming(w http.ResponseWriter, r *http.Request) {
+	runs := []UpcomingRun{}
+	if a.scheduler == nil {
+		writeJSON(w, http.StatusOK, runs)
+		return
+	}
+
+	n := defaultUpcoming
+	if s := r.URL.Query().Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 || v > maxUpcoming {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 n 参数 %q，应为 1 到 %d", s, maxUpcoming))
+			return
+		}
+		n = v
+	}
+
+	plans, err := scheduler.Plan(a.cfg, time.Now(), n)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for _, plan := range plans {
+		paused := a.store.Paused(plan.Schedule.Name)
+		for _, run := range plan.Runs {
+			runs = append(runs, UpcomingRun{
+				Schedule:   plan.Schedule.Name,
+				Fire:       run.Fire,
+				Latest:     run.Latest,
+				Paused:     paused,
+				Skipped:    run.Skipped,
+				SkipReason: run.SkipReason,
+			})
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Fire.Before(runs[j].Fire) })
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (a *API) pauseSchedule(w http.ResponseWriter, r *http.Request) {
+	a.setPaused(w, r, true)
+}
+
+func (a *API) resumeSchedule(w http.ResponseWriter, r *http.Request) {
+	a.setPaused(w, r, false)
+}
+
+func (a *API) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if a.scheduler == nil {
+		writeError(w, http.StatusNotFound, errors.New("守护进程未以定时模式运行"))
+		return
+	}
+
+	name := r.PathValue("name")
+	var err error
+	if paused {
+		err = a.scheduler.Pause(name)
+	} else {
+		err = a.scheduler.Resume(name)
+	}
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownSchedule):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, st := range a.scheduler.Schedules() {
+		if st.Name == name {
+			writeJSON(w, http.StatusOK, st)
+			return
+		}
+	}
+}
+
+// listHistory 返回运行记录及按账号的统计，支持 account、since、status、task_type 和 limit 参数
+func (a *API) listHistory(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := history.Query{
+		Account:  params.Get("account"),
+		TaskType: params.Get("task_type"),
+		Limit:    defaultHistoryLimit,
+	}
+
+	if s := params.Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 since 参数 %q，应为 RFC 3339 时间", s))
+			return
+		}
+		q.Since = t
+	}
+	switch status := params.Get("status"); status {
+	case "":
+	case "success", "failure":
+		ok := status == "success"
+		q.Success = &ok
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 status 参数 %q，应为 success 或 failure", status))
+		return
+	}
+	if s := params.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 limit 参数 %q", s))
+			return
+		}
+		q.Limit = n
+	}
+
+	records, err := a.hist.List(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("查询运行记录失败: %w", err))
+		return
+	}
+	if records == nil {
+		records = []history.Record{}
+	}
+	// status 和 limit 只过滤返回的记录，统计仍包含范围内的全部运行
+	loc, err := time.LoadLocation(a.cfg.Scheduler.Timezone)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("无效的时区 %q: %w", a.cfg.Scheduler.Timezone, err))
+		return
+	}
+	stats, err := history.Stats(a.hist, q, loc)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("统计运行记录失败: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Records []history.Record       `json:"records"`
+		Stats   []history.AccountStats `json:"stats"`
+	}{Records: records, Stats: stats})
+}
+
+// checkAccount 检查路径中的账号是否为已配置的账号
+func (a *API) checkAccount(w http.ResponseWriter, r *http.Request) bool {
+	if account := r.PathValue("account"); account != a.cfg.User.Username {
+		writeError(w, http.StatusNotFound, fmt.Errorf("未知的账号: %s", account))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/runner"
+	"zhxg-signin/internal/scheduler"
+	"zhxg-signin/internal/server"
+	"zhxg-signin/internal/state"
+)
+
+const (
+	testToken = "api-token"
+	userToken = "user-token"
+)
+
+// testAPI 是挂载在测试服务上的控制接口
+type testAPI struct {
+	url       string
+	statePath string
+}
+
+// newTestAPI 启动带有一个定时计划 morning 的控制接口，签到服务不会被访问
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	cfg := config.Config{
+		User:     config.UserConfig{Username: "20230001", Password: "password"},
+		Location: config.LocationConfig{Longitude: 109.4, Latitude: 24.3},
+		SignIn:   config.SignInConfig{BaseURL: "http://127.0.0.1:1"},
+		Scheduler: config.SchedulerConfig{
+			Timezone:  "Asia/Shanghai",
+			Schedules: []config.ScheduleConfig{{Name: "morning", Cron: "0 8 * * *"}},
+		},
+		Server: config.ServerConfig{APIToken: testToken, UserToken: userToken},
+	}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	store, err := state.Open(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist, err := history.Open(config.HistoryConfig{Backend: history.BackendMemory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(cfg, store, hist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched, err := scheduler.New(cfg, store, hist, r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.New(cfg.Server)
+	New(cfg, store, hist, r, sched).Register(srv)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return &testAPI{url: ts.URL, statePath: statePath}
+}
+
+// do 发送请求并返回状态码和响应体，token 为空时不带 Authorization 请求头
+func (a *testAPI) do(t *testing.T, method, path, token, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, a.url+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(raw)
+}
+
+func TestAuth(t *testing.T) {
+	a := newTestAPI(t)
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "not-the-token", http.StatusUnauthorized},
+		{"correct", testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, body := a.do(t, http.MethodGet, "/api/v1/accounts", tt.token, ""); code != tt.want {
+				t.Errorf("status = %d, want %d: %s", code, tt.want, body)
+			}
+		})
+	}
+
+	// OpenAPI 描述不需要 token
+	if code, _ := a.do(t, http.MethodGet, "/api/v1/openapi.json", "", ""); code != http.StatusOK {
+		t.Errorf("openapi.json status = %d, want 200", code)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	a := newTestAPI(t)
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/api/v1/accounts/20239999/run", ""},
+		{http.MethodGet, "/api/v1/accounts/20239999/tasks", ""},
+		{http.MethodPut, "/api/v1/accounts/20239999/location", `{"longitude":109.4,"latitude":24.3}`},
+		{http.MethodPost, "/api/v1/schedules/evening/pause", ""},
+		{http.MethodPost, "/api/v1/schedules/evening/resume", ""},
+	}
+	for _, tt := range tests {
+		if code, body := a.do(t, tt.method, tt.path, testToken, tt.body); code != http.StatusNotFound {
+			t.Errorf("%s %s status = %d, want 404: %s", tt.method, tt.path, code, body)
+		}
+	}
+}
+
+func TestPauseResumePersisted(t *testing.T) {
+	a := newTestAPI(t)
+	paused := func() bool {
+		t.Helper()
+		// 重新打开状态文件，确认修改已写入磁盘
+		store, err := state.Open(a.statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store.Paused("morning")
+	}
+
+	code, body := a.do(t, http.MethodPost, "/api/v1/schedules/morning/pause", testToken, "")
+	if code != http.StatusOK {
+		t.Fatalf("pause status = %d: %s", code, body)
+	}
+	var st scheduler.ScheduleStatus
+	if err := json.Unmarshal([]byte(body), &st); err != nil {
+		t.Fatal(err)
+	}
+	if !st.Paused || !paused() {
+		t.Errorf("schedule not paused: %s", body)
+	}
+
+	if code, body := a.do(t, http.MethodPost, "/api/v1/schedules/morning/resume", testToken, ""); code != http.StatusOK {
+		t.Fatalf("resume status = %d: %s", code, body)
+	}
+	if paused() {
+		t.Error("schedule still paused after resume")
+	}
+}
+
+func TestUpdateLocation(t *testing.T) {
+	a := newTestAPI(t)
+	for _, body := range []string{`{"longitude":200,"latitude":24.3}`, `{"longitude":0,"latitude":0}`, `not json`} {
+		if code, _ := a.do(t, http.MethodPut, "/api/v1/accounts/20230001/location", testToken, body); code != http.StatusBadRequest {
+			t.Errorf("PUT location %s status = %d, want 400", body, code)
+		}
+	}
+
+	code, body := a.do(t, http.MethodPut, "/api/v1/accounts/20230001/location", testToken, `{"longitude":110.1,"latitude":25.2}`)
+	if code != http.StatusOK {
+		t.Fatalf("PUT location status = %d: %s", code, body)
+	}
+	var loc Location
+	if err := json.Unmarshal([]byte(body), &loc); err != nil {
+		t.Fatal(err)
+	}
+	if loc.Longitude != 110.1 || loc.Latitude != 25.2 || !loc.Overridden {
+		t.Errorf("location = %+v", loc)
+	}
+}
+
+func TestQueryValidation(t *testing.T) {
+	a := newTestAPI(t)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/upcoming", http.StatusOK},
+		{"/api/v1/upcoming?n=3", http.StatusOK},
+		{"/api/v1/upcoming?n=0", http.StatusBadRequest},
+		{"/api/v1/upcoming?n=51", http.StatusBadRequest},
+		{"/api/v1/upcoming?n=abc", http.StatusBadRequest},
+		{"/api/v1/history?limit=10", http.StatusOK},
+		{"/api/v1/history?limit=0", http.StatusOK},
+		{"/api/v1/history?limit=-1", http.StatusBadRequest},
+		{"/api/v1/history?limit=abc", http.StatusBadRequest},
+		{"/api/v1/history?status=maybe", http.StatusBadRequest},
+		{"/api/v1/history?since=yesterday", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code, body := a.do(t, http.MethodGet, tt.path, testToken, ""); code != tt.want {
+			t.Errorf("GET %s status = %d, want %d: %s", tt.path, code, tt.want, body)
+		}
+	}
+
+	// n 参数决定每个计划返回的执行次数
+	_, body := a.do(t, http.MethodGet, "/api/v1/upcoming?n=3", testToken, "")
+	var runs []UpcomingRun
+	if err := json.Unmarshal([]byte(body), &runs); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Errorf("got %d upcoming runs, want 3", len(runs))
+	}
+}
+
+func TestUserTokenRole(t *testing.T) {
+	a := newTestAPI(t)
+
+	// 普通用户可以查看状态
+	for _, path := range []string{"/api/v1/accounts", "/api/v1/schedules", "/api/v1/upcoming", "/api/v1/history"} {
+		if code, body := a.do(t, http.MethodGet, path, userToken, ""); code != http.StatusOK {
+			t.Errorf("GET %s with the user token status = %d: %s", path, code, body)
+		}
+	}
+
+	// 修改位置和暂停、恢复计划仅限管理员
+	admin := []struct {
+		method, path, body string
+	}{
+		{http.MethodPut, "/api/v1/accounts/20230001/location", `{"longitude":110.1,"latitude":25.2}`},
+		{http.MethodPost, "/api/v1/schedules/morning/pause", ""},
+		{http.MethodPost, "/api/v1/schedules/morning/resume", ""},
+	}
+	for _, tt := range admin {
+		if code, body := a.do(t, tt.method, tt.path, userToken, tt.body); code != http.StatusForbidden {
+			t.Errorf("%s %s with the user token status = %d, want 403: %s", tt.method, tt.path, code, body)
+		}
+	}
+	store, err := state.Open(a.statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, overridden := store.Location("20230001"); overridden || store.Paused("morning") {
+		t.Error("the user token changed the state")
+	}
+
+	tests := []struct {
+		token string
+		admin bool
+	}{
+		{testToken, true},
+		{userToken, false},
+	}
+	for _, tt := range tests {
+		code, body := a.do(t, http.MethodGet, "/api/v1/me", tt.token, "")
+		if code != http.StatusOK || body != fmt.Sprintf("{\"admin\":%v}\n", tt.admin) {
+			t.Errorf("GET /me with %q = %d %s, want admin %v", tt.token, code, body, tt.admin)
+		}
+	}
+}
+
+// 未配置 user_token 时不能用空 token 访问
+func TestEmptyUserToken(t *testing.T) {
+	a := &API{cfg: config.Config{Server: config.ServerConfig{APIToken: testToken}}}
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	if _, ok := a.role(r); ok {
+		t.Error("an empty token was accepted")
+	}
+}
@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/notify"
+	"zhxg-signin/internal/runner"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/telegram"
+)
+
+const (
+	defaultPollTimeout = 30 * time.Second
+	historyLimit       = 10
+)
+
+const helpText = `可用命令：
+/status - 查看账号最近一次签到状态
+/tasks - 查看当前未签到的任务
+/run <账号> - 立即为该账号执行一次签到
+/history - 查看最近的签到记录`
+
+// Telegram 是以长轮询方式响应聊天命令的 Telegram 机器人
+type Telegram struct {
+	cfg     config.Config
+	client  *telegram.Client
+	store   *state.Store
+	hist    history.Store
+	runner  *runner.Runner
+	allowed map[int64]bool
+	log     *zap.Logger
+}
+
+// NewTelegram 创建一个新的 Telegram 机器人
+func NewTelegram(cfg config.Config, store *state.Store, hist history.Store, r *runner.Runner) (*Telegram, error) {
+	tc := cfg.Notify.Telegram
+	if tc.Token == "" || len(tc.ChatIDs) == 0 {
+		return nil, fmt.Errorf("telegram: token 和 chat_ids 不能为空")
+	}
+
+	allowed := make(map[int64]bool, len(tc.ChatIDs))
+	for _, id := range tc.ChatIDs {
+		allowed[id] = true
+	}
+
+	return &Telegram{
+		cfg:     cfg,
+		client:  telegram.NewClient(tc.APIBase, tc.Token),
+		store:   store,
+		hist:    hist,
+		runner:  r,
+		allowed: allowed,
+		log:     logger.GetLogger(),
+	}, nil
+}
+
+// Start 开始长轮询并处理命令，该方法会一直阻塞
+func (b *Telegram) Start() {
+	timeout := b.cfg.Notify.Telegram.PollTimeout
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+
+	b.log.Info("Telegram 机器人已启动", zap.Int("chats", len(b.allowed)))
+
+	var offset int64
+	for {
+		next, err := b.poll(offset, timeout)
+		if err != nil {
+			b.log.Warn("获取 Telegram 更新失败", zap.Error(err))
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		offset = next
+	}
+}
+
+// poll 获取一次 offset 之后的更新并回复其中的命令，返回下一次轮询的 offset
+func (b *Telegram) poll(offset int64, timeout time.Duration) (int64, error) {
+	updates, err := b.client.GetUpdates(offset, timeout)
+	if err != nil {
+		return offset, err
+	}
+
+	for _, u := range updates {
+		offset = u.UpdateID + 1
+		if u.Message == nil || !strings.HasPrefix(u.Message.Text, "/") {
+			continue
+		}
+
+		chatID := u.Message.Chat.ID
+		if !b.allowed[chatID] {
+			b.log.Warn("忽略非白名单聊天的命令", zap.Int64("chat_id", chatID), zap.String("text", u.Message.Text))
+			continue
+		}
+
+		reply := b.handle(u.Message.Text)
+		if err := b.client.SendMessage(chatID, reply); err != nil {
+			b.log.Warn("发送 Telegram 回复失败", zap.Int64("chat_id", chatID), zap.Error(err))
+		}
+	}
+	return offset, nil
+}
+
+// handle 执行一条命令并返回回复内容
+func (b *Telegram) handle(text string) string {
+	fields := strings.Fields(text)
+	// 群聊中的命令可能带有 @机器人名 后缀
+	command, _, _ := strings.Cut(fields[0], "@")
+	b.log.Info("收到 Telegram 命令", zap.String("command", command))
+
+	switch command {
+	case "/status":
+		return b.status()
+	case "/tasks":
+		return b.tasks()
+	case "/run":
+		if len(fields) < 2 {
+			return "用法: /run <账号>"
+		}
+		return b.run(fields[1])
+	case "/history":
+		return b.recent()
+	default:
+		return helpText
+	}
+}
+
+func (b *Telegram) status() string {
+	account := b.cfg.User.Username
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "账号: %s\n", account)
+
+	ok, known := b.store.LastStatus(account)
+	switch {
+	case !known:
+		sb.WriteString("最近状态: 暂无记录\n")
+	case ok:
+		sb.WriteString("最近状态: 成功\n")
+	default:
+		sb.WriteString("最近状态: 失败\n")
+	}
+
+	runs, err := b.hist.List(history.Query{Account: account, Limit: 1})
+	if err != nil {
+		fmt.Fprintf(&sb, "查询运行记录失败: %v\n", err)
+	} else if len(runs) > 0 {
+		last := runs[0]
+		fmt.Fprintf(&sb, "最近运行: %s (%s)\n", last.StartedAt.Format("2006-01-02 15:04:05"), last.Schedule)
+		if last.Error != "" {
+			fmt.Fprintf(&sb, "错误 (%s): %s\n", last.ErrorClass, last.Error)
+		}
+	}
+
+	for _, sc := range b.cfg.Scheduler.ScheduleList() {
+		if last := b.store.LastRun(sc.Name); !last.IsZero() {
+			fmt.Fprintf(&sb, "计划 %s 最近成功: %s\n", sc.Name, last.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return sb.String()
+}
+
+func (b *Telegram) tasks() string {
+	tasks, err := b.runner.PendingTasks()
+	if err != nil {
+		return "获取未签到任务失败: " + err.Error()
+	}
+	if len(tasks) == 0 {
+		return "没有需要签到的任务"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 %d 个未签到任务：\n", len(tasks))
+	for _, t := range tasks {
+		fmt.Fprintf(&sb, "- %s (ID: %d, 批次: %d)\n", t.SigninTypeName, t.ID, t.BatchNo)
+	}
+	return sb.String()
+}
+
+func (b *Telegram) run(account string) string {
+	if account != b.cfg.User.Username {
+		return fmt.Sprintf("未知的账号: %s", account)
+	}
+	res := b.runner.Run("telegram")
+	return notify.Title(res) + "\n\n" + notify.Text(res)
+}
+
+func (b *Telegram) recent() string {
+	runs, err := b.hist.List(history.Query{Limit: historyLimit})
+	if err != nil {
+		return "查询运行记录失败: " + err.Error()
+	}
+	if len(runs) == 0 {
+		return "暂无签到记录"
+	}
+
+	var sb strings.Builder
+	for _, run := range runs {
+		result := "成功"
+		if !run.Success {
+			result = fmt.Sprintf("失败 (%s): %s", run.ErrorClass, run.Error)
+		}
+		fmt.Fprintf(&sb, "%s %s [%s] %s\n", run.StartedAt.Format("01-02 15:04"), run.DisplayName(), run.Schedule, result)
+	}
+	return sb.String()
+}
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/client"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/runner"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/telegram"
+	"zhxg-signin/internal/wisestu"
+	"zhxg-signin/internal/wisestutest"
+)
+
+const (
+	testToken   = "123456:bot-secret"
+	allowedChat = 1001
+	otherChat   = 2002
+)
+
+// sent 是机器人发送的一条消息
+type sent struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// botAPI 是 Telegram Bot API 的替身，getUpdates 返回预置的更新，sendMessage 记录发送的消息
+type botAPI struct {
+	mu      sync.Mutex
+	updates []telegram.Update
+	sent    []sent
+}
+
+func (a *botAPI) command(id int64, chatID int64, text string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	msg := &telegram.Message{MessageID: id, Text: text}
+	msg.Chat.ID = chatID
+	a.updates = append(a.updates, telegram.Update{UpdateID: id, Message: msg})
+}
+
+func (a *botAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var result interface{} = true
+	switch r.URL.Path {
+	case "/bot" + testToken + "/getUpdates":
+		var req struct {
+			Offset int64 `json:"offset"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var updates []telegram.Update
+		for _, u := range a.updates {
+			if u.UpdateID >= req.Offset {
+				updates = append(updates, u)
+			}
+		}
+		result = updates
+	case "/bot" + testToken + "/sendMessage":
+		var msg sent
+		json.NewDecoder(r.Body).Decode(&msg)
+		a.sent = append(a.sent, msg)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Not Found"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// replies 返回发送到各聊天的消息，并清空记录
+func (a *botAPI) replies() []sent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := a.sent
+	a.sent = nil
+	return out
+}
+
+// mockSolver 直接从模拟服务查询验证码答案
+type mockSolver struct{ mock *wisestutest.Server }
+
+func (m mockSolver) Name() string { return "mock" }
+
+func (m mockSolver) SolveCaptcha(image string) (int, error) {
+	answer, ok := m.mock.AnswerImage(image)
+	if !ok {
+		return 0, errors.New("未知的验证码")
+	}
+	return answer, nil
+}
+
+// newTestBot 创建访问 Bot API 替身的机器人，签到请求发往智慧学工模拟服务
+func newTestBot(t *testing.T) (*Telegram, *botAPI, history.Store) {
+	t.Helper()
+	api := &botAPI{}
+	tg := httptest.NewServer(api)
+	t.Cleanup(tg.Close)
+
+	mock, srv := wisestutest.NewServer(wisestutest.Options{})
+	t.Cleanup(srv.Close)
+
+	cfg := config.Config{
+		User:     config.UserConfig{Username: "20230001", Password: "password"},
+		Location: config.LocationConfig{Longitude: 109.4, Latitude: 24.3},
+		SignIn:   config.SignInConfig{RetryInterval: time.Millisecond},
+		Notify: config.NotifyConfig{Telegram: config.TelegramConfig{
+			Commands: true,
+			Token:    testToken,
+			APIBase:  tg.URL,
+			ChatIDs:  []int64{allowedChat},
+		}},
+	}
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist, err := history.Open(config.HistoryConfig{Backend: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(cfg, store, hist,
+		signin.WithAPIClient(wisestu.New(client.NewHTTPClient(srv.URL, false))),
+		signin.WithSolver(mockSolver{mock}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewTelegram(cfg, store, hist, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, api, hist
+}
+
+func TestTelegramAllowList(t *testing.T) {
+	b, api, _ := newTestBot(t)
+	api.command(1, otherChat, "/history")
+	api.command(2, allowedChat, "/help")
+	api.command(3, allowedChat, "不是命令")
+
+	offset, err := b.poll(0, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 4 {
+		t.Errorf("offset = %d, want 4", offset)
+	}
+	got := api.replies()
+	if len(got) != 1 || got[0].ChatID != allowedChat || got[0].Text != helpText {
+		t.Errorf("replies = %+v, want the help text to chat %d only", got, allowedChat)
+	}
+
+	// 已处理的更新不会重复回复
+	if _, err := b.poll(offset, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.replies(); len(got) != 0 {
+		t.Errorf("replied again: %+v", got)
+	}
+}
+
+func TestTelegramRunAndHistory(t *testing.T) {
+	b, api, hist := newTestBot(t)
+
+	api.command(1, allowedChat, "/history")
+	api.command(2, allowedChat, "/run 20239999")
+	api.command(3, allowedChat, "/run@zhxg_bot 20230001")
+	api.command(4, allowedChat, "/history")
+	if _, err := b.poll(0, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	got := api.replies()
+	if len(got) != 4 {
+		t.Fatalf("replies = %+v, want 4", got)
+	}
+	if got[0].Text != "暂无签到记录" {
+		t.Errorf("/history before any run = %q", got[0].Text)
+	}
+	if got[1].Text != "未知的账号: 20239999" {
+		t.Errorf("/run with an unknown account = %q", got[1].Text)
+	}
+	if !strings.Contains(got[2].Text, "20230001") {
+		t.Errorf("/run reply = %q", got[2].Text)
+	}
+	runs, err := hist.List(history.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Schedule != "telegram" || !runs[0].Success {
+		t.Fatalf("history = %+v, want one successful run from telegram", runs)
+	}
+	if !strings.Contains(got[3].Text, "[telegram]") {
+		t.Errorf("/history after a run = %q", got[3].Text)
+	}
+}
@@ -0,0 +1,218 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zhxg-signin/internal/config"
+)
+
+const dateLayout = "2006-01-02"
+
+// Calendar 存储节假日、调休工作日以及用户自定义的跳过日期
+type Calendar struct {
+	holidays     map[string]string
+	workdays     map[string]string
+	skips        map[string]struct{}
+	workdaysOnly bool
+}
+
+// fileFormat 是 JSON 日历文件的结构，键为日期，值为名称
+type fileFormat struct {
+	Holidays map[string]string `json:"holidays"`
+	Workdays map[string]string `json:"workdays"`
+}
+
+// Load 根据配置加载日历，未启用时返回 nil
+func Load(cfg config.CalendarConfig) (*Calendar, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	c := &Calendar{
+		holidays:     make(map[string]string),
+		workdays:     make(map[string]string),
+		skips:        make(map[string]struct{}),
+		workdaysOnly: cfg.WorkdaysOnly,
+	}
+
+	for _, file := range cfg.Files {
+		var err error
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".ics":
+			err = c.loadICS(file)
+		default:
+			err = c.loadJSON(file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("加载日历文件 %s 失败: %w", file, err)
+		}
+	}
+
+	for _, item := range cfg.SkipDates {
+		if err := c.addSkip(item); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Check 判断给定时间所在日期是否应跳过签到，并返回原因
+func (c *Calendar) Check(t time.Time) (bool, string) {
+	if c == nil {
+		return false, ""
+	}
+
+	day := t.Format(dateLayout)
+	if _, ok := c.skips[day]; ok {
+		return true, "用户配置的跳过日期"
+	}
+	if name, ok := c.holidays[day]; ok {
+		return true, "法定节假日: " + name
+	}
+	if !c.workdaysOnly {
+		return false, ""
+	}
+	if _, ok := c.workdays[day]; ok {
+		return false, ""
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return true, "周末非调休工作日"
+	}
+	return false, ""
+}
+
+func (c *Calendar) loadJSON(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	for day, name := range f.Holidays {
+		if _, err := time.Parse(dateLayout, day); err != nil {
+			return fmt.Errorf("无效的节假日日期 %q: %w", day, err)
+		}
+		c.holidays[day] = name
+	}
+	for day, name := range f.Workdays {
+		if _, err := time.Parse(dateLayout, day); err != nil {
+			return fmt.Errorf("无效的调休日期 %q: %w", day, err)
+		}
+		c.workdays[day] = name
+	}
+	return nil
+}
+
+// loadICS 解析全天事件，SUMMARY 中包含“班”的视为调休工作日，其余视为节假日
+func (c *Calendar) loadICS(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lines, err := unfoldICS(f)
+	if err != nil {
+		return err
+	}
+
+	var inEvent bool
+	var summary string
+	var start, end time.Time
+
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(key, ";")
+
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent, summary, start, end = true, "", time.Time{}, time.Time{}
+		case line == "END:VEVENT":
+			if !inEvent || start.IsZero() {
+				inEvent = false
+				continue
+			}
+			if end.IsZero() {
+				end = start.AddDate(0, 0, 1)
+			}
+			target := c.holidays
+			if strings.Contains(summary, "班") {
+				target = c.workdays
+			}
+			// DTEND 为不包含的结束日期
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				target[d.Format(dateLayout)] = summary
+			}
+			inEvent = false
+		case !inEvent:
+			continue
+		case name == "SUMMARY":
+			summary = value
+		case name == "DTSTART":
+			if start, err = parseICSDate(value); err != nil {
+				return err
+			}
+		case name == "DTEND":
+			if end, err = parseICSDate(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unfoldICS 按 RFC 5545 读取内容行，以空格或制表符开头的行是上一行的折叠续行
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if n := len(lines); n > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			lines[n-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("无效的 ICS 日期 %q", value)
+	}
+	return time.Parse("20060102", value[:8])
+}
+
+// addSkip 添加跳过日期，支持 "2025-10-10" 和 "2025-10-10~2025-10-12" 两种写法
+func (c *Calendar) addSkip(item string) error {
+	from, to, isRange := strings.Cut(item, "~")
+	start, err := time.Parse(dateLayout, strings.TrimSpace(from))
+	if err != nil {
+		return fmt.Errorf("无效的跳过日期 %q: %w", item, err)
+	}
+	end := start
+	if isRange {
+		if end, err = time.Parse(dateLayout, strings.TrimSpace(to)); err != nil {
+			return fmt.Errorf("无效的跳过日期 %q: %w", item, err)
+		}
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		c.skips[d.Format(dateLayout)] = struct{}{}
+	}
+	return nil
+}
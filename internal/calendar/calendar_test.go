@@ -0,0 +1,54 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/config"
+)
+
+func TestLoadICSUnfoldsLines(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:2025\r\n" +
+		" 1001\r\n" +
+		"DTEND;VALUE=DATE:20251003\r\n" +
+		"SUMMARY:国庆节\r\n" +
+		"\t假期\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20250928\r\n" +
+		"SUMMARY:国庆节调休上\r\n" +
+		" 班\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	file := filepath.Join(t.TempDir(), "holidays.ics")
+	if err := os.WriteFile(file, []byte(ics), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cal, err := Load(config.CalendarConfig{Enabled: true, Files: []string{file}, WorkdaysOnly: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		date   string
+		skip   bool
+		reason string
+	}{
+		{"2025-10-01", true, "法定节假日: 国庆节假期"},
+		{"2025-10-02", true, "法定节假日: 国庆节假期"},
+		{"2025-10-03", false, ""},
+		{"2025-09-28", false, ""}, // 周日调休上班
+	}
+	for _, tt := range tests {
+		day, _ := time.Parse(dateLayout, tt.date)
+		skip, reason := cal.Check(day)
+		if skip != tt.skip || (tt.reason != "" && reason != tt.reason) {
+			t.Errorf("Check(%s) = %v, %q, want %v, %q", tt.date, skip, reason, tt.skip, tt.reason)
+		}
+	}
+}
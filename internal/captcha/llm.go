@@ -1,8 +1,14 @@
 package captcha
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"zhxg-signin/internal/config"
@@ -10,18 +16,85 @@ import (
 
 // LLMClient 用于与 LLM API 交互
 type LLMClient struct {
-	client *resty.Client
-	cfg    config.LLMConfig
+	client   *resty.Client
+	cfg      config.LLMConfig
+	observer func(d time.Duration, status string, usage Usage)
 }
 
+// Usage 是 LLM API 返回的 token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// defaultTimeout 是未配置 llm.timeout 时单次请求的超时时间
+const defaultTimeout = 30 * time.Second
+
 // NewLLMClient 创建一个新的 LLMClient
 func NewLLMClient(cfg config.LLMConfig, debug bool) *LLMClient { // <--- 接收 debug 参数
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	// 没有超时时模型卡住会让整个签到流程一直等待
 	client := resty.New().
 		SetAuthToken(cfg.APIKey).
+		SetTimeout(timeout).
 		SetDebug(debug) // <--- 根据参数设置调试模式
 	return &LLMClient{client: client, cfg: cfg}
 }
 
+// Name 返回识别器名称，用于记录使用的模型
+func (c *LLMClient) Name() string {
+	return "llm:" + c.cfg.Model
+}
+
+// LLM 请求的结果，HTTP 错误时为响应状态码，如 "401"、"429"
+const (
+	StatusOK              = "ok"               // 收到并解析了响应
+	StatusTransportError  = "transport_error"  // 请求未收到响应，如连接失败或超时
+	StatusInvalidResponse = "invalid_response" // 响应不是合法的 JSON
+)
+
+// SetObserver 设置每次 LLM 请求结束后的回调，用于统计耗时、结果和 token 用量。
+// 请求失败时也会调用，status 为 StatusOK、StatusTransportError、StatusInvalidResponse 或 HTTP 状态码
+func (c *LLMClient) SetObserver(fn func(d time.Duration, status string, usage Usage)) {
+	c.observer = fn
+}
+
+// WrapTransport 用 wrap 包装底层的 HTTP transport，用于录制和回放请求
+func (c *LLMClient) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.client.SetTransport(wrap(c.client.GetClient().Transport))
+}
+
+// Ping 检查 LLM API 是否可用，返回响应状态。
+// 发送 messages 为空的请求，配置正确时接口返回 400 且不消耗 token；
+// 兼容 OpenAI 的接口对 GET 请求也返回 404，因此不能用 GET 探测。
+// 401、403 和 404 说明 API Key、地址或模型配置有误，429 和 5xx 说明接口限流或故障，
+// 这些情况下签到时的请求也会失败，视为不可用
+func (c *LLMClient) Ping(ctx context.Context) (string, error) {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{
+			"model":      c.cfg.Model,
+			"messages":   []interface{}{},
+			"max_tokens": 1,
+		}).
+		Post(c.cfg.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	switch code := resp.StatusCode(); {
+	case code == http.StatusUnauthorized, code == http.StatusForbidden, code == http.StatusNotFound:
+		return resp.Status(), fmt.Errorf("LLM API 返回 %s，请检查 llm.api_key、llm.endpoint 和 llm.model", resp.Status())
+	case code == http.StatusTooManyRequests, code >= http.StatusInternalServerError:
+		// 限流或服务端故障时识别验证码同样会失败
+		return resp.Status(), fmt.Errorf("LLM API 暂时不可用: %s", resp.Status())
+	}
+	return resp.Status(), nil
+}
+
 // SolveCaptcha 使用 LLM API 解决验证码
 func (c *LLMClient) SolveCaptcha(imageBase64 string) (int, error) {
 	prompt := `你是一个精准的图像计算器。你的任务是识别下图中的数学算式并计算出结果。请严格遵循以下步骤和格式：
@@ -31,6 +104,15 @@ func (c *LLMClient) SolveCaptcha(imageBase64 string) (int, error) {
 
 **示例**：如果图片内容是 '5 + 3 =', 你应该返回 {"expression": "5+3", "result": 8, "error": null}`
 
+	start := time.Now()
+	status := StatusTransportError
+	var usage Usage
+	defer func() {
+		if c.observer != nil {
+			c.observer(time.Since(start), status, usage)
+		}
+	}()
+
 	resp, err := c.client.R().
 		SetHeader("Content-Type", "application/json").
 		SetBody(map[string]interface{}{
@@ -63,6 +145,7 @@ func (c *LLMClient) SolveCaptcha(imageBase64 string) (int, error) {
 	}
 
 	if resp.IsError() {
+		status = strconv.Itoa(resp.StatusCode())
 		return 0, errors.New("LLM API 请求失败: " + resp.Status())
 	}
 
@@ -72,11 +155,14 @@ func (c *LLMClient) SolveCaptcha(imageBase64 string) (int, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(resp.Body(), &llmResp); err != nil {
+		status = StatusInvalidResponse
 		return 0, err
 	}
+	status, usage = StatusOK, llmResp.Usage
 
 	if len(llmResp.Choices) == 0 {
 		return 0, errors.New("LLM 响应为空")
@@ -86,9 +172,21 @@ func (c *LLMClient) SolveCaptcha(imageBase64 string) (int, error) {
 		Result int `json:"result"`
 	}
 
-	if err := json.Unmarshal([]byte(llmResp.Choices[0].Message.Content), &result); err != nil {
+	if err := json.Unmarshal([]byte(stripFence(llmResp.Choices[0].Message.Content)), &result); err != nil {
 		return 0, err
 	}
 
 	return result.Result, nil
+}
+
+// stripFence 去掉模型有时包裹在 JSON 外面的 Markdown 代码块标记
+func stripFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimPrefix(content, "json")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package captcha_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/captcha"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/llmtest"
+)
+
+// newClient 创建访问 endpoint 的 LLMClient，并记录观察者收到的结果
+func newClient(endpoint string) (*captcha.LLMClient, *[]string) {
+	c := captcha.NewLLMClient(config.LLMConfig{APIKey: "sk-test", Endpoint: endpoint, Model: "gpt-4.1-mini"}, false)
+	var statuses []string
+	c.SetObserver(func(d time.Duration, status string, usage captcha.Usage) {
+		statuses = append(statuses, status)
+	})
+	return c, &statuses
+}
+
+func TestObserverOnEveryRequest(t *testing.T) {
+	_, srv := llmtest.NewServer(
+		llmtest.JSON(8),
+		llmtest.Unauthorized(),
+		llmtest.NotFound(),
+		llmtest.RateLimited(20*time.Second),
+		llmtest.EmptyChoices(),
+	)
+	c, statuses := newClient(srv.URL + "/v1/chat/completions")
+	for i := 0; i < 5; i++ {
+		c.SolveCaptcha("aW1hZ2U=")
+	}
+
+	// 服务关闭后请求没有响应
+	srv.Close()
+	c.SolveCaptcha("aW1hZ2U=")
+
+	want := []string{captcha.StatusOK, "401", "404", "429", captcha.StatusOK, captcha.StatusTransportError}
+	if !slices.Equal(*statuses, want) {
+		t.Errorf("observed %v, want %v", *statuses, want)
+	}
+}
+
+func TestSolveCaptcha(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   llmtest.Reply
+		want    int
+		wantErr string // 错误信息应包含的内容，为空表示不应出错
+	}{
+		{name: "json", reply: llmtest.JSON(8), want: 8},
+		{name: "fenced json", reply: llmtest.FencedJSON(12), want: 12},
+		// 客户端不校验算式，计算错误的结果会原样返回，由登录时的验证码错误触发重试
+		{name: "wrong arithmetic", reply: llmtest.WrongArithmetic(7), want: 7},
+		{name: "empty choices", reply: llmtest.EmptyChoices(), wantErr: "LLM 响应为空"},
+		{name: "unauthorized", reply: llmtest.Unauthorized(), wantErr: "401"},
+		{name: "not found", reply: llmtest.NotFound(), wantErr: "404"},
+		{name: "rate limited", reply: llmtest.RateLimited(20 * time.Second), wantErr: "429"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm, srv := llmtest.NewServer(tt.reply)
+			defer srv.Close()
+			c, _ := newClient(srv.URL + "/v1/chat/completions")
+
+			got, err := c.SolveCaptcha("aW1hZ2U=")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("SolveCaptcha: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("SolveCaptcha error = %v, want it to contain %q", err, tt.wantErr)
+			case tt.wantErr == "" && got != tt.want:
+				t.Errorf("SolveCaptcha = %d, want %d", got, tt.want)
+			}
+
+			// 出错时不自行重试，429 的 Retry-After 也交给登录循环的重试间隔处理
+			reqs := llm.Requests()
+			if len(reqs) != 1 {
+				t.Fatalf("server received %d requests, want 1", len(reqs))
+			}
+			if reqs[0].Authorization != "Bearer sk-test" || reqs[0].Model != "gpt-4.1-mini" || reqs[0].Image != "aW1hZ2U=" {
+				t.Errorf("unexpected request %+v", reqs[0])
+			}
+		})
+	}
+}
+
+func TestSolveCaptchaSlow(t *testing.T) {
+	_, srv := llmtest.NewServer(llmtest.Slow(50*time.Millisecond, llmtest.JSON(3)))
+	defer srv.Close()
+	c, _ := newClient(srv.URL + "/v1/chat/completions")
+
+	start := time.Now()
+	got, err := c.SolveCaptcha("aW1hZ2U=")
+	if err != nil || got != 3 {
+		t.Fatalf("SolveCaptcha = %d, %v", got, err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("returned after %v, before the reply was sent", d)
+	}
+}
+
+func TestSolveCaptchaTimeout(t *testing.T) {
+	_, srv := llmtest.NewServer(llmtest.Slow(300*time.Millisecond, llmtest.JSON(3)))
+	defer srv.Close()
+	c := captcha.NewLLMClient(config.LLMConfig{APIKey: "sk-test", Endpoint: srv.URL + "/v1/chat/completions", Model: "gpt-4.1-mini", Timeout: 50 * time.Millisecond}, false)
+	var statuses []string
+	c.SetObserver(func(d time.Duration, status string, usage captcha.Usage) {
+		statuses = append(statuses, status)
+	})
+
+	start := time.Now()
+	if _, err := c.SolveCaptcha("aW1hZ2U="); err == nil {
+		t.Fatal("SolveCaptcha succeeded after the timeout")
+	}
+	if d := time.Since(start); d >= 300*time.Millisecond {
+		t.Errorf("returned after %v, want the 50ms timeout to fire first", d)
+	}
+	if !slices.Equal(statuses, []string{captcha.StatusTransportError}) {
+		t.Errorf("observed %v, want a transport error", statuses)
+	}
+}
+
+func TestPingDoesNotConsumeReplies(t *testing.T) {
+	llm, srv := llmtest.NewServer(llmtest.JSON(8))
+	defer srv.Close()
+	c, _ := newClient(srv.URL + "/v1/chat/completions")
+
+	// messages 为空的探测请求返回 400，视为可用
+	status, err := c.Ping(context.Background())
+	if err != nil || status != "400 Bad Request" {
+		t.Fatalf("Ping = %q, %v", status, err)
+	}
+	if got, err := c.SolveCaptcha("aW1hZ2U="); err != nil || got != 8 || len(llm.Requests()) != 1 {
+		t.Errorf("SolveCaptcha = %d, %v after %d requests", got, err, len(llm.Requests()))
+	}
+}
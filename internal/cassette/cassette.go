@@ -0,0 +1,283 @@
+// Package cassette 录制和回放 HTTP 请求，用于把一次真实的签到会话保存为可重复运行的回归用例。
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 录制模式
+const (
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Redacted 是脱敏后替换敏感内容的占位符
+const Redacted = "[REDACTED]"
+
+// redactedHeaders 是需要脱敏的请求头和响应头，保留其是否存在以便复现缺少请求头的问题
+var redactedHeaders = []string{"Authorization", "Token", "Cookie", "Set-Cookie"}
+
+// redactedFields 是需要脱敏的 JSON 字段
+var redactedFields = map[string]bool{
+	"password":           true,
+	"token":              true,
+	"api_key":            true,
+	"verification_image": true,
+	"image":              true, // 拍照签到上传的照片
+}
+
+// Request 是录制的请求
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response 是录制的响应
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Interaction 是一次请求及其响应
+type Interaction struct {
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Cassette 是保存在一个 JSON 文件中的一组请求和响应
+type Cassette struct {
+	path string
+	mode string
+
+	mu           sync.Mutex
+	Interactions []Interaction `json:"interactions"`
+	used         []bool
+}
+
+// Open 打开 cassette 文件。录制模式下会覆盖已有文件，回放模式下文件必须存在
+func Open(path, mode string) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	switch mode {
+	case ModeRecord:
+		if err := c.save(); err != nil {
+			return nil, err
+		}
+	case ModeReplay:
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取 cassette 失败: %w", err)
+		}
+		if err := json.Unmarshal(raw, c); err != nil {
+			return nil, fmt.Errorf("解析 cassette %s 失败: %w", path, err)
+		}
+		c.used = make([]bool, len(c.Interactions))
+	default:
+		return nil, fmt.Errorf("无效的 cassette 模式 %q，应为 record 或 replay", mode)
+	}
+	return c, nil
+}
+
+// Wrap 返回录制或回放用的 transport，录制模式下通过 base 发送真实请求，回放模式下不会访问网络
+func (c *Cassette) Wrap(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		if c.mode == ModeReplay {
+			return c.replay(req)
+		}
+		return c.record(base, req)
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (c *Cassette) record(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(req.Header),
+			Body:   redactBody(reqBody),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: redactHeader(resp.Header),
+			Body:   redactBody(respBody),
+		},
+		RecordedAt: time.Now(),
+	})
+	// 每次请求后立即保存，进程中途退出也不会丢失已录制的内容
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay 按录制顺序返回第一个尚未使用且方法、路径和 action 都相同的响应
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	key := matchKey(req.Method, req.URL.Path, reqBody)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, it := range c.Interactions {
+		if c.used[i] {
+			continue
+		}
+		u, err := req.URL.Parse(it.Request.URL)
+		if err != nil || matchKey(it.Request.Method, u.Path, []byte(it.Request.Body)) != key {
+			continue
+		}
+		c.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+			StatusCode:    it.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        it.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(it.Response.Body)),
+			ContentLength: int64(len(it.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s 中没有匹配的请求: %s", filepath.Base(c.path), key)
+}
+
+// matchKey 返回回放时匹配请求用的键，智慧学工的接口按请求体中的 action 区分
+func matchKey(method, path string, body []byte) string {
+	var v struct {
+		Action string `json:"action"`
+	}
+	json.Unmarshal(body, &v)
+	if v.Action == "" {
+		return method + " " + path
+	}
+	return method + " " + path + " (" + v.Action + ")"
+}
+
+func (c *Cassette) save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 cassette 失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("创建 cassette 目录失败: %w", err)
+	}
+	return os.WriteFile(c.path, raw, 0o644)
+}
+
+// readBody 读取并还原 body，使其仍可被后续处理读取
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取 body 失败: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range redactedHeaders {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+// redactBody 脱敏 JSON 中的密码、token 和图片，非 JSON 内容原样保存
+func redactBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	raw, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(raw)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if redactedFields[k] {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = redactValue(val)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactValue(val)
+		}
+		return v
+	case string:
+		// LLM 请求中以 data URL 形式携带的验证码图片
+		if prefix, _, ok := strings.Cut(v, "base64,"); ok && strings.HasPrefix(prefix, "data:image/") {
+			return prefix + "base64," + Redacted
+		}
+		// 智慧学工请求体中以字符串形式嵌套的 JSON，如 client_extra
+		if strings.HasPrefix(v, "{") {
+			var nested interface{}
+			if json.Unmarshal([]byte(v), &nested) == nil {
+				before, _ := json.Marshal(nested)
+				// 没有需要脱敏的内容时保留原文，避免改变字段顺序和格式
+				if raw, err := json.Marshal(redactValue(nested)); err == nil && !bytes.Equal(raw, before) {
+					return string(raw)
+				}
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
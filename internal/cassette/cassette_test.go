@@ -0,0 +1,119 @@
+package cassette
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"zhxg-signin/internal/client"
+	"zhxg-signin/internal/wisestu"
+	"zhxg-signin/internal/wisestutest"
+)
+
+const testPassword = "s3cret-Pa55word"
+
+// newAPI 创建访问 baseURL 的智慧学工客户端，请求经过 cassette 录制或回放
+func newAPI(t *testing.T, baseURL, path, mode string) *wisestu.Client {
+	t.Helper()
+	c, err := Open(path, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := wisestu.New(client.NewHTTPClient(baseURL, false))
+	api.WrapTransport(c.Wrap)
+	return api
+}
+
+// recordLogin 对模拟服务完成一次登录并查询学生信息，返回登录用的验证码和 token
+func recordLogin(t *testing.T, path string) (*wisestu.Verification, string) {
+	t.Helper()
+	mock, srv := wisestutest.NewServer(wisestutest.Options{Password: testPassword})
+	defer srv.Close()
+	api := newAPI(t, srv.URL, path, ModeRecord)
+
+	v, err := api.QueryVerificationQuestion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, _ := mock.Answer(v.VerificationID)
+	token, err := api.LoginStudent("20230001", testPassword, v, strconv.Itoa(answer))
+	if err != nil {
+		t.Fatalf("LoginStudent: %v", err)
+	}
+	api.SetAuthToken(token)
+	if _, err := api.QueryMyStuInfo(); err != nil {
+		t.Fatalf("QueryMyStuInfo: %v", err)
+	}
+	return v, token
+}
+
+func TestRecordRedacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wisestu.json")
+	v, token := recordLogin(t, path)
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := map[string]string{
+		"password":           testPassword,
+		"token":              token,
+		"verification_image": v.VerificationImage,
+	}
+	for name, secret := range secrets {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette contains the %s %q", name, secret)
+		}
+	}
+	if !strings.Contains(string(raw), Redacted) {
+		t.Error("cassette contains no redaction placeholder")
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wisestu.json")
+	recordLogin(t, path)
+
+	// 回放时不会访问网络，地址指向一个不存在的服务
+	api := newAPI(t, "http://127.0.0.1:1", path, ModeReplay)
+
+	// 按 action 匹配，不要求与录制时的顺序一致
+	student, err := api.QueryMyStuInfo()
+	if err != nil {
+		t.Fatalf("QueryMyStuInfo: %v", err)
+	}
+	if student.RealName != "张三" {
+		t.Errorf("RealName = %q", student.RealName)
+	}
+	v, err := api.QueryVerificationQuestion()
+	if err != nil {
+		t.Fatalf("QueryVerificationQuestion: %v", err)
+	}
+	token, err := api.LoginStudent("20230001", "another password", v, "0")
+	if err != nil {
+		t.Fatalf("LoginStudent: %v", err)
+	}
+	if token != Redacted {
+		t.Errorf("token = %q, want the redacted placeholder", token)
+	}
+
+	// 每条录制的响应只使用一次
+	if _, err := api.QueryMyStuInfo(); err == nil || !strings.Contains(err.Error(), "没有匹配的请求") {
+		t.Errorf("second QueryMyStuInfo error = %v, want no match", err)
+	}
+	// 没有录制过的 action
+	if _, err := api.GetUnSigninList(1, 10); err == nil || !strings.Contains(err.Error(), "getUnSigninList") {
+		t.Errorf("GetUnSigninList error = %v, want no match", err)
+	}
+}
+
+func TestOpenReplayMissing(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Error("Open succeeded without a cassette file")
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "c.json"), "rewind"); err == nil {
+		t.Error("Open accepted an unknown mode")
+	}
+}
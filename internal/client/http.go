@@ -1,8 +1,11 @@
 package client
 
 import (
-	"github.com/go-resty/resty/v2"
+	"encoding/json"
+	"net/http"
 	"time"
+
+	"github.com/go-resty/resty/v2"
 )
 
 // HTTPClient 是一个封装了 resty.Client 的结构体
@@ -25,6 +28,41 @@ func NewHTTPClient(baseURL string, debug bool) *HTTPClient {
 	}
 }
 
+// SetObserver 设置请求完成后的回调，用于按 action 统计请求耗时
+func (c *HTTPClient) SetObserver(fn func(action string, d time.Duration, err error)) {
+	c.client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		fn(actionOf(resp.Request.Body), resp.Time(), nil)
+		return nil
+	})
+	c.client.OnError(func(req *resty.Request, err error) {
+		fn(actionOf(req.Body), time.Since(req.Time), err)
+	})
+}
+
+// actionOf 从请求体中取出 action 字段
+func actionOf(body interface{}) string {
+	var raw []byte
+	switch b := body.(type) {
+	case string:
+		raw = []byte(b)
+	case []byte:
+		raw = b
+	default:
+		raw, _ = json.Marshal(b)
+	}
+
+	var v struct {
+		Action string `json:"action"`
+	}
+	json.Unmarshal(raw, &v)
+	return v.Action
+}
+
+// WrapTransport 用 wrap 包装底层的 HTTP transport，用于录制和回放请求
+func (c *HTTPClient) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.client.SetTransport(wrap(c.client.GetClient().Transport))
+}
+
 // SetAuthToken 设置并存储认证 token
 func (c *HTTPClient) SetAuthToken(token string) {
 	c.token = token
@@ -38,4 +76,25 @@ func (c *HTTPClient) R() *resty.Request {
 		req.SetHeader("Authorization", c.token)
 	}
 	return req
+}
+
+// Response 是接口响应的状态码、响应头和响应体
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IsError 返回 HTTP 状态码是否表示请求失败
+func (r *Response) IsError() bool {
+	return r.StatusCode > 399
+}
+
+// Post 以 JSON 格式发送 body 到 path，并返回完整的响应
+func (c *HTTPClient) Post(path string, body interface{}) (*Response, error) {
+	resp, err := c.R().SetBody(body).Post(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{StatusCode: resp.StatusCode(), Header: resp.Header(), Body: resp.Body()}, nil
 }
\ No newline at end of file
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -14,6 +19,13 @@ type Config struct {
 	LLM       LLMConfig       `mapstructure:"llm"`
 	SignIn    SignInConfig    `mapstructure:"signin"`
 	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	Watch     WatchConfig     `mapstructure:"watch"`
+	Calendar  CalendarConfig  `mapstructure:"calendar"`
+	State     StateConfig     `mapstructure:"state"`
+	History   HistoryConfig   `mapstructure:"history"`
+	Notify    NotifyConfig    `mapstructure:"notifications"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Cassette  CassetteConfig  `mapstructure:"cassette"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
 }
 
@@ -21,6 +33,7 @@ type Config struct {
 type UserConfig struct {
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
+	RealName string `mapstructure:"real_name"` // 账号对应的真实姓名，设置后与学生信息不一致时不会提交签到
 }
 
 // LocationConfig 存储地理位置信息
@@ -31,9 +44,10 @@ type LocationConfig struct {
 
 // LLMConfig 存储 LLM API 的配置
 type LLMConfig struct {
-	APIKey   string `mapstructure:"api_key"`
-	Endpoint string `mapstructure:"endpoint"`
-	Model    string `mapstructure:"model"`
+	APIKey   string        `mapstructure:"api_key"`
+	Endpoint string        `mapstructure:"endpoint"`
+	Model    string        `mapstructure:"model"`
+	Timeout  time.Duration `mapstructure:"timeout"` // 单次请求的超时时间，0 表示使用默认的 30 秒
 }
 
 // SignInConfig 存储签到相关的配置
@@ -41,13 +55,317 @@ type SignInConfig struct {
 	BaseURL       string        `mapstructure:"base_url"`
 	RetryTimes    int           `mapstructure:"retry_times"`
 	RetryInterval time.Duration `mapstructure:"retry_interval"`
+
+	// Flows 是任务类型到签到方式的映射，取值为 location、photo、click 或 skip，
+	// 为空时等同于 {实习: location}
+	Flows       map[string]string `mapstructure:"flows"`
+	DefaultFlow string            `mapstructure:"default_flow"` // 未配置类型的签到方式，默认为 auto
+	Strategies  StrategiesConfig  `mapstructure:"strategies"`
+}
+
+// StrategiesConfig 存储各签到方式的配置
+type StrategiesConfig struct {
+	Location LocationStrategyConfig `mapstructure:"location"`
+	Photo    PhotoStrategyConfig    `mapstructure:"photo"`
+	Click    ClickStrategyConfig    `mapstructure:"click"`
+}
+
+// LocationStrategyConfig 存储位置签到的配置
+type LocationStrategyConfig struct {
+	OutsideFlag string `mapstructure:"outside_flag"` // 提交的 outside_flag，默认为 "1"
+}
+
+// PhotoStrategyConfig 存储拍照签到时上传的照片
+type PhotoStrategyConfig struct {
+	Files []string `mapstructure:"files"`
+	Dir   string   `mapstructure:"dir"` // 目录中的 jpg、jpeg 和 png 图片都会作为候选
+}
+
+// ClickStrategyConfig 存储点击签到的配置
+type ClickStrategyConfig struct {
+	RequireInside bool `mapstructure:"require_inside"` // 服务器判定在签到范围外时视为失败
 }
 
 // SchedulerConfig 存储定时任务的配置
 type SchedulerConfig struct {
+	Enabled   bool             `mapstructure:"enabled"`
+	Cron      string           `mapstructure:"cron"`
+	Window    Window           `mapstructure:"window"`
+	Timezone  string           `mapstructure:"timezone"`
+	Seed      int64            `mapstructure:"seed"` // 随机延迟的种子，0 表示使用当前时间
+	Schedules []ScheduleConfig `mapstructure:"schedules"`
+	CatchUp   CatchUpConfig    `mapstructure:"catchup"`
+}
+
+// CatchUpConfig 存储错过执行后补签的配置
+type CatchUpConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Lookback time.Duration `mapstructure:"lookback"` // 守护进程启动时向前检查错过执行的时长
+}
+
+// ScheduleConfig 存储单个定时计划的配置
+type ScheduleConfig struct {
+	Name   string `mapstructure:"name"`
+	Cron   string `mapstructure:"cron"`
+	Window Window `mapstructure:"window"` // 触发后在窗口内随机选择实际执行时间
+}
+
+// Validate 检查时段写法的执行窗口是否与 cron 表达式的触发时间一致。
+// 时段是一天中固定的时间，cron 在一天中多个时刻触发（如 "0 8,14,18 * * 1"）时无法对应，
+// 这种情况应将 window 写成时长
+func (sc ScheduleConfig) Validate() error {
+	if sc.Window.Ranged && !sc.Window.firesAtStart(sc.Cron) {
+		return fmt.Errorf("定时计划 %s 的 cron %q 应只在执行窗口 %s 的开始时间触发，分钟和小时字段须为单个数值；"+
+			"cron 每天触发多次时请将 window 写成时长，如 \"25m\"", sc.Name, sc.Cron, sc.Window)
+	}
+	return nil
+}
+
+// Window 是定时计划的执行窗口，可以写成时长（如 "25m"），也可以写成时段（如 "08:00-08:25"）。
+// 写成时段时 cron 需在时段开始时触发，未配置 cron 时按时段开始时间每天触发
+type Window struct {
+	Length time.Duration // 窗口长度
+	Start  time.Duration // 时段写法的开始时间距零点的偏移
+	Ranged bool          // 是否为时段写法
+}
+
+// ParseWindow 解析时长或 "HH:MM-HH:MM" 形式的执行窗口，空字符串表示没有窗口
+func ParseWindow(s string) (Window, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Window{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Window{}, fmt.Errorf("无效的执行窗口 %q，应为时长或 HH:MM-HH:MM", s)
+		}
+		return Window{Length: d}, nil
+	}
+	start, err := ParseClock(from)
+	if err != nil {
+		return Window{}, err
+	}
+	end, err := ParseClock(to)
+	if err != nil {
+		return Window{}, err
+	}
+	if end <= start {
+		return Window{}, fmt.Errorf("无效的执行窗口 %q，结束时间应晚于开始时间", s)
+	}
+	return Window{Length: end - start, Start: start, Ranged: true}, nil
+}
+
+// ParseClock 将 "HH:MM" 形式的时间解析为距零点的时长
+func ParseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间 %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String 按配置时的写法返回执行窗口
+func (w Window) String() string {
+	if !w.Ranged {
+		return w.Length.String()
+	}
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.Start+w.Length)
+}
+
+// cron 返回在时段开始时间每天触发的 cron 表达式
+func (w Window) cron() string {
+	return fmt.Sprintf("%d %d * * *", int(w.Start.Minutes())%60, int(w.Start.Hours()))
+}
+
+// firesAtStart 检查 cron 表达式的分钟和小时字段是否恰好为时段的开始时间，
+// 字段为列表、范围或步长时返回 false
+func (w Window) firesAtStart(spec string) bool {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return false
+	}
+	minute, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return false
+	}
+	hour, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return false
+	}
+	return minute == int(w.Start.Minutes())%60 && hour == int(w.Start.Hours())
+}
+
+// windowHook 将配置中的字符串解析为 Window。
+// 不带单位的整数含义不明确，除 0 表示没有窗口外一律报错
+func windowHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != reflect.TypeOf(Window{}) {
+		return data, nil
+	}
+	switch v := data.(type) {
+	case string:
+		return ParseWindow(v)
+	case int, int64, float64:
+		if reflect.ValueOf(v).IsZero() {
+			return Window{}, nil
+		}
+		return nil, fmt.Errorf("执行窗口 %v 缺少单位，应写成带单位的时长（如 \"25m\"）或 HH:MM-HH:MM", v)
+	}
+	return data, nil
+}
+
+// ScheduleList 返回所有定时计划，未配置 schedules 时使用顶层的 cron 和 window
+func (c SchedulerConfig) ScheduleList() []ScheduleConfig {
+	if len(c.Schedules) > 0 {
+		list := make([]ScheduleConfig, len(c.Schedules))
+		for i, sc := range c.Schedules {
+			if sc.Name == "" {
+				sc.Name = fmt.Sprintf("schedule-%d", i+1)
+			}
+			if sc.Cron == "" && sc.Window.Ranged {
+				sc.Cron = sc.Window.cron()
+			}
+			list[i] = sc
+		}
+		return list
+	}
+	sc := ScheduleConfig{Name: "default", Cron: c.Cron, Window: c.Window}
+	if sc.Cron == "" {
+		if !sc.Window.Ranged {
+			return nil
+		}
+		sc.Cron = sc.Window.cron()
+	}
+	return []ScheduleConfig{sc}
+}
+
+// WatchConfig 存储轮询模式的配置
+type WatchConfig struct {
+	Interval    time.Duration `mapstructure:"interval"`     // 轮询间隔
+	MaxInterval time.Duration `mapstructure:"max_interval"` // 没有待签到任务时退避的最大间隔
+	ActiveHours string        `mapstructure:"active_hours"` // 轮询时段，如 "07:00-22:00"，为空表示全天
+	TaskTypes   []string      `mapstructure:"task_types"`   // 需要签到的任务类型，为空表示全部
+}
+
+// CalendarConfig 存储节假日日历的配置
+type CalendarConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Files        []string `mapstructure:"files"`         // JSON 或 ICS 格式的节假日文件
+	SkipDates    []string `mapstructure:"skip_dates"`    // 用户自定义的跳过日期，如请假
+	WorkdaysOnly bool     `mapstructure:"workdays_only"` // 仅在工作日（含调休上班日）执行
+}
+
+// StateConfig 存储运行状态持久化的配置
+type StateConfig struct {
+	File string `mapstructure:"file"` // 为空时不持久化
+}
+
+// HistoryConfig 存储运行记录的配置
+type HistoryConfig struct {
+	Backend   string        `mapstructure:"backend"`   // bolt 或 memory
+	Path      string        `mapstructure:"path"`      // bolt 数据库文件路径
+	Retention time.Duration `mapstructure:"retention"` // 记录保留时长，0 表示永久保留
+}
+
+// ServerConfig 存储守护进程内置 HTTP 服务的配置
+type ServerConfig struct {
+	Listen    string `mapstructure:"listen"`     // 监听地址，如 127.0.0.1:9090，为空时不启动
+	APIToken  string `mapstructure:"api_token"`  // 控制接口的管理员 Bearer token，为空时不启用控制接口
+	UserToken string `mapstructure:"user_token"` // 普通用户的 token，只能查看和立即签到，为空时只能使用 api_token
+}
+
+// CassetteConfig 存储 HTTP 请求录制和回放的配置
+type CassetteConfig struct {
+	Mode string `mapstructure:"mode"` // record 或 replay，为空时不启用
+	Dir  string `mapstructure:"dir"`  // cassette 文件所在目录
+}
+
+// NotifyConfig 存储通知相关的配置
+type NotifyConfig struct {
+	On         string           `mapstructure:"on"` // 通知策略：always、failure 或 change
+	Webhook    WebhookConfig    `mapstructure:"webhook"`
+	Email      EmailConfig      `mapstructure:"email"`
+	DingTalk   RobotConfig      `mapstructure:"dingtalk"`
+	WeCom      RobotConfig      `mapstructure:"wecom"`
+	Feishu     RobotConfig      `mapstructure:"feishu"`
+	ServerChan ServerChanConfig `mapstructure:"serverchan"`
+	Bark       BarkConfig       `mapstructure:"bark"`
+	Telegram   TelegramConfig   `mapstructure:"telegram"`
+}
+
+// RobotConfig 存储钉钉、企业微信、飞书等群机器人的配置
+type RobotConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	On      string `mapstructure:"on"`
+	Webhook string `mapstructure:"webhook"`
+	Secret  string `mapstructure:"secret"` // 钉钉、飞书的加签密钥，企业微信不需要
+}
+
+// ServerChanConfig 存储 Server 酱推送的配置
+type ServerChanConfig struct {
 	Enabled  bool   `mapstructure:"enabled"`
-	Cron     string `mapstructure:"cron"`
-	Timezone string `mapstructure:"timezone"`
+	On       string `mapstructure:"on"`
+	SendKey  string `mapstructure:"sendkey"`
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// BarkConfig 存储 Bark 推送的配置
+type BarkConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	On        string `mapstructure:"on"`
+	Server    string `mapstructure:"server"`
+	DeviceKey string `mapstructure:"device_key"`
+	Group     string `mapstructure:"group"`
+}
+
+// WebhookConfig 存储通用 Webhook 通知的配置
+type WebhookConfig struct {
+	Enabled         bool              `mapstructure:"enabled"`
+	On              string            `mapstructure:"on"`
+	URL             string            `mapstructure:"url"`
+	Method          string            `mapstructure:"method"`
+	Headers         map[string]string `mapstructure:"headers"`
+	Body            string            `mapstructure:"body"`             // text/template 模板，数据为签到结果
+	Secret          string            `mapstructure:"secret"`           // 不为空时对请求体进行 HMAC-SHA256 签名
+	SignatureHeader string            `mapstructure:"signature_header"` // 签名所在的请求头，默认 X-Signature
+	Retries         int               `mapstructure:"retries"`          // 5xx 或网络错误时的重试次数
+	RetryInterval   time.Duration     `mapstructure:"retry_interval"`
+}
+
+// EmailConfig 存储邮件通知的配置
+type EmailConfig struct {
+	Enabled            bool         `mapstructure:"enabled"`
+	On                 string       `mapstructure:"on"`
+	Host               string       `mapstructure:"host"`
+	Port               int          `mapstructure:"port"`
+	Security           string       `mapstructure:"security"` // starttls、tls 或 none
+	InsecureSkipVerify bool         `mapstructure:"insecure_skip_verify"`
+	Username           string       `mapstructure:"username"`
+	Password           string       `mapstructure:"password"`
+	From               string       `mapstructure:"from"`
+	To                 []string     `mapstructure:"to"`
+	Digest             DigestConfig `mapstructure:"digest"`
+}
+
+// DigestConfig 存储每日汇总邮件的配置
+type DigestConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Time    string `mapstructure:"time"` // 每天发送的时间，如 "21:30"
+}
+
+// TelegramConfig 存储 Telegram 机器人的配置
+type TelegramConfig struct {
+	Enabled     bool          `mapstructure:"enabled"` // 是否推送签到结果
+	On          string        `mapstructure:"on"`
+	Commands    bool          `mapstructure:"commands"` // 守护进程中是否响应聊天命令
+	Token       string        `mapstructure:"token"`
+	APIBase     string        `mapstructure:"api_base"` // Bot API 地址，可指向本地替身服务
+	ChatIDs     []int64       `mapstructure:"chat_ids"` // 允许交互并接收推送的聊天
+	PollTimeout time.Duration `mapstructure:"poll_timeout"`
 }
 
 // LoggingConfig 存储日志相关的配置
@@ -74,6 +392,35 @@ func LoadConfig(path string) (config Config, err error) {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	err = viper.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		windowHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)))
 	return
-}
\ No newline at end of file
+}
+
+// Validate 检查运行签到所必需的配置项。
+// signin.flows 和 signin.default_flow 可以引用通过 signin.WithStrategy 注册的签到方式，
+// 由 signin.Service.ValidateFlows 对照注册表检查，这里不做检查
+func (c Config) Validate() error {
+	var errs []error
+	if c.User.Username == "" || c.User.Password == "" {
+		errs = append(errs, errors.New("user.username 和 user.password 不能为空"))
+	}
+	if c.LLM.APIKey == "" || c.LLM.Endpoint == "" || c.LLM.Model == "" {
+		errs = append(errs, errors.New("llm.api_key、llm.endpoint 和 llm.model 不能为空"))
+	}
+	if c.SignIn.BaseURL == "" {
+		errs = append(errs, errors.New("signin.base_url 不能为空"))
+	}
+	if _, err := time.LoadLocation(c.Scheduler.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("无效的 scheduler.timezone: %w", err))
+	}
+	for _, sc := range c.Scheduler.ScheduleList() {
+		if err := sc.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Window
+		wantErr bool
+	}{
+		{in: "", want: Window{}},
+		{in: "0s", want: Window{}},
+		{in: "25m", want: Window{Length: 25 * time.Minute}},
+		{in: "1h30m", want: Window{Length: 90 * time.Minute}},
+		{in: "08:00-08:25", want: Window{Length: 25 * time.Minute, Start: 8 * time.Hour, Ranged: true}},
+		{in: " 21:30 - 22:00 ", want: Window{Length: 30 * time.Minute, Start: 21*time.Hour + 30*time.Minute, Ranged: true}},
+		{in: "25", wantErr: true},
+		{in: "soon", wantErr: true},
+		{in: "08:25-08:00", wantErr: true},
+		{in: "08:00-08:00", wantErr: true},
+		{in: "8点-9点", wantErr: true},
+		{in: "25:00-26:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseWindow(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindow(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseWindow(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	ranged, err := ParseWindow("08:00-08:25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cron    string
+		window  Window
+		wantErr bool
+	}{
+		{name: "时长窗口", cron: "0 8,14,18 * * 1", window: Window{Length: 25 * time.Minute}},
+		{name: "在时段开始时触发", cron: "0 8 * * 1-5", window: ranged},
+		{name: "字段带前导零", cron: "00 08 * * *", window: ranged},
+		{name: "每天触发多次", cron: "0 8,14,18 * * 1", window: ranged, wantErr: true},
+		{name: "小时为范围", cron: "0 8-9 * * *", window: ranged, wantErr: true},
+		{name: "触发时间不一致", cron: "30 8 * * *", window: ranged, wantErr: true},
+		{name: "字段数不对", cron: "0 8 * *", window: ranged, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ScheduleConfig{Name: "test", Cron: tt.cron, Window: tt.window}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowHook(t *testing.T) {
+	to := reflect.TypeOf(Window{})
+	tests := []struct {
+		in      interface{}
+		want    Window
+		wantErr bool
+	}{
+		{in: "15m", want: Window{Length: 15 * time.Minute}},
+		{in: 0, want: Window{}},
+		{in: 300, wantErr: true},
+		{in: int64(300), wantErr: true},
+		{in: 1.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := windowHook(reflect.TypeOf(tt.in), to, tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("windowHook(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("windowHook(%v) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// 不带单位的整数窗口在加载配置时报错，而不是被当作纳秒
+func TestLoadConfigBareIntegerWindow(t *testing.T) {
+	dir := t.TempDir()
+	yaml := "scheduler:\n  cron: \"0 8 * * *\"\n  window: 300\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(dir); err == nil {
+		t.Error("LoadConfig accepted a window without a unit")
+	}
+}
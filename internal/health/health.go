@@ -0,0 +1,219 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"zhxg-signin/internal/captcha"
+	"zhxg-signin/internal/config"
+)
+
+const (
+	llmProbeTimeout = 5 * time.Second
+	llmProbeCache   = time.Minute
+)
+
+// Monitor 保存各组件的心跳和各账号最近一次会话检查的结果，供 /healthz 和 /readyz 使用。
+// nil *Monitor 不记录任何内容，不需要健康检查时可以直接传入 nil
+type Monitor struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	beats     map[string]beat
+	sessions  map[string]Check
+}
+
+// NewMonitor 创建一个新的 Monitor
+func NewMonitor() *Monitor {
+	return &Monitor{
+		startedAt: time.Now(),
+		beats:     make(map[string]beat),
+		sessions:  make(map[string]Check),
+	}
+}
+
+// beat 是组件最近一次上报的心跳
+type beat struct {
+	at  time.Time
+	ttl time.Duration
+}
+
+// Check 是单项检查的结果
+type Check struct {
+	OK        bool      `json:"ok"`
+	CheckedAt time.Time `json:"checked_at"`
+	Detail    string    `json:"detail,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func newCheck(at time.Time, detail string, err error) Check {
+	c := Check{OK: err == nil, CheckedAt: at, Detail: detail}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// Beat 上报组件心跳，组件承诺在 ttl 内再次上报，超时未上报视为卡死
+func (m *Monitor) Beat(component string, ttl time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.beats[component] = beat{at: time.Now(), ttl: ttl}
+}
+
+// RecordSession 记录账号最近一次会话检查的结果，err 为 nil 表示会话有效
+func (m *Monitor) RecordSession(account string, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[account] = newCheck(time.Now(), "", err)
+}
+
+// session 返回账号最近一次会话检查的结果
+func (m *Monitor) session(account string) (Check, bool) {
+	if m == nil {
+		return Check{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.sessions[account]
+	return c, ok
+}
+
+// ComponentStatus 是 /healthz 中单个组件的状态
+type ComponentStatus struct {
+	Alive    bool      `json:"alive"`
+	LastBeat time.Time `json:"last_beat"`
+}
+
+// Liveness 是 /healthz 的响应内容
+type Liveness struct {
+	Status     string                     `json:"status"`
+	StartedAt  time.Time                  `json:"started_at"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// LivenessHandler 返回 /healthz 的处理器，任一组件心跳超时时返回 503
+func (m *Monitor) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		body := Liveness{Status: "ok", StartedAt: m.startedAt, Components: make(map[string]ComponentStatus)}
+
+		m.mu.Lock()
+		for name, b := range m.beats {
+			alive := now.Sub(b.at) <= b.ttl
+			body.Components[name] = ComponentStatus{Alive: alive, LastBeat: b.at}
+			if !alive {
+				body.Status = "stalled"
+			}
+		}
+		m.mu.Unlock()
+
+		code := http.StatusOK
+		if body.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, body)
+	})
+}
+
+// AccountReadiness 是单个账号的就绪状态
+type AccountReadiness struct {
+	Account string `json:"account"`
+	Ready   bool   `json:"ready"`
+	Session Check  `json:"session"`
+}
+
+// Readiness 是 /readyz 的响应内容
+type Readiness struct {
+	Ready    bool               `json:"ready"`
+	Checks   map[string]Check   `json:"checks"`
+	Accounts []AccountReadiness `json:"accounts"`
+}
+
+// Checker 汇总配置、LLM 可达性和各账号会话状态，判断服务是否就绪
+type Checker struct {
+	cfg     config.Config
+	flows   func() error
+	llm     *captcha.LLMClient
+	monitor *Monitor
+
+	mu       sync.Mutex
+	llmCheck Check
+}
+
+// NewChecker 创建一个新的就绪检查器，账号的会话状态从 monitor 中读取。
+// flows 检查配置的签到方式是否已注册，如 Runner.ValidateFlows，为 nil 时不检查
+func NewChecker(cfg config.Config, monitor *Monitor, flows func() error) *Checker {
+	return &Checker{cfg: cfg, flows: flows, llm: captcha.NewLLMClient(cfg.LLM, false), monitor: monitor}
+}
+
+// Check 执行一次就绪检查
+func (c *Checker) Check(ctx context.Context) Readiness {
+	cfgErr := c.cfg.Validate()
+	if c.flows != nil {
+		cfgErr = errors.Join(cfgErr, c.flows())
+	}
+	body := Readiness{
+		Checks: map[string]Check{
+			"config": newCheck(time.Now(), "", cfgErr),
+			"llm":    c.checkLLM(ctx),
+		},
+	}
+
+	// 尚未检查过会话时视为就绪，登录失败等问题会在第一次签到后反映出来
+	account := c.cfg.User.Username
+	session, ok := c.monitor.session(account)
+	if !ok {
+		session = Check{OK: true, Detail: "尚未检查会话，将在下次签到时登录"}
+	}
+	body.Accounts = append(body.Accounts, AccountReadiness{Account: account, Ready: session.OK, Session: session})
+
+	body.Ready = true
+	for _, check := range body.Checks {
+		body.Ready = body.Ready && check.OK
+	}
+	for _, acc := range body.Accounts {
+		body.Ready = body.Ready && acc.Ready
+	}
+	return body
+}
+
+// checkLLM 探测 LLM API 是否可达，结果缓存一段时间以免频繁探测
+func (c *Checker) checkLLM(ctx context.Context) Check {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.llmCheck.CheckedAt.IsZero() && time.Since(c.llmCheck.CheckedAt) < llmProbeCache {
+		return c.llmCheck
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, llmProbeTimeout)
+	defer cancel()
+	status, err := c.llm.Ping(ctx)
+	c.llmCheck = newCheck(time.Now(), status, err)
+	return c.llmCheck
+}
+
+// ServeHTTP 处理 /readyz，未就绪时返回 503
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := c.Check(r.Context())
+	code := http.StatusOK
+	if !body.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, body)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
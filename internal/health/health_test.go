@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/config"
+)
+
+// newTestChecker 创建检查器，LLM 接口对任意请求返回 status
+func newTestChecker(t *testing.T, status int, mon *Monitor) *Checker {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.Config{
+		User:      config.UserConfig{Username: "20230001", Password: "password"},
+		LLM:       config.LLMConfig{APIKey: "sk-test", Endpoint: srv.URL + "/v1/chat/completions", Model: "gpt-4.1-mini"},
+		SignIn:    config.SignInConfig{BaseURL: "http://127.0.0.1"},
+		Scheduler: config.SchedulerConfig{Timezone: "Asia/Shanghai"},
+	}
+	return NewChecker(cfg, mon, nil)
+}
+
+func TestCheckerLLMStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		ready  bool
+	}{
+		// 探测请求的 messages 为空，配置正确时返回 400
+		{http.StatusBadRequest, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusNotFound, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			body := newTestChecker(t, tt.status, NewMonitor()).Check(context.Background())
+			if body.Checks["llm"].OK != tt.ready || body.Ready != tt.ready {
+				t.Errorf("ready = %v, llm = %+v, want ready %v", body.Ready, body.Checks["llm"], tt.ready)
+			}
+		})
+	}
+}
+
+func TestCheckerSession(t *testing.T) {
+	mon := NewMonitor()
+	c := newTestChecker(t, http.StatusBadRequest, mon)
+
+	// 尚未检查会话时不应阻塞就绪
+	if body := c.Check(context.Background()); !body.Ready {
+		t.Errorf("not ready before any session check: %+v", body)
+	}
+
+	mon.RecordSession("20230001", errors.New("登录失败"))
+	if body := c.Check(context.Background()); body.Ready || body.Accounts[0].Session.Error != "登录失败" {
+		t.Errorf("ready after a failed session check: %+v", body)
+	}
+
+	mon.RecordSession("20230001", nil)
+	if body := c.Check(context.Background()); !body.Ready {
+		t.Errorf("not ready after a successful session check: %+v", body)
+	}
+}
+
+func TestLivenessStalled(t *testing.T) {
+	mon := NewMonitor()
+	handler := mon.LivenessHandler()
+	get := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+
+	mon.Beat("scheduler", time.Minute)
+	if code := get(); code != http.StatusOK {
+		t.Errorf("status = %d after a fresh beat, want 200", code)
+	}
+
+	// 上报的 ttl 已过期
+	mon.Beat("watcher", -time.Second)
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d after a missed beat, want 503", code)
+	}
+}
+
+func TestNilMonitor(t *testing.T) {
+	var mon *Monitor
+	mon.Beat("scheduler", time.Minute)
+	mon.RecordSession("20230001", nil)
+	if _, ok := mon.session("20230001"); ok {
+		t.Error("nil monitor recorded a session")
+	}
+}
+
+func TestCheckerFlows(t *testing.T) {
+	cfg := newTestChecker(t, http.StatusBadRequest, nil).cfg
+
+	// 签到方式的检查由调用方提供，以便识别自定义注册的签到方式
+	c := NewChecker(cfg, nil, func() error { return errors.New("signin.flows 中 人脸 的签到方式 \"face\" 无效") })
+	if body := c.Check(context.Background()); body.Ready || body.Checks["config"].OK {
+		t.Errorf("ready with an invalid flow: %+v", body)
+	}
+	c = NewChecker(cfg, nil, func() error { return nil })
+	if body := c.Check(context.Background()); !body.Ready {
+		t.Errorf("not ready with valid flows: %+v", body)
+	}
+}
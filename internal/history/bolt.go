@@ -0,0 +1,143 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+	"zhxg-signin/internal/logger"
+)
+
+var runsBucket = []byte("runs")
+
+// boltStore 将运行记录保存在 bbolt 数据库中，键为自增 ID
+//
+// bbolt 同一时间只允许一个进程写入，因此每次操作时才打开数据库，
+// 使守护进程运行期间仍可以通过 history 命令查询。
+type boltStore struct {
+	path string
+}
+
+func newBoltStore(path string) *boltStore {
+	return &boltStore{path: path}
+}
+
+func (s *boltStore) withDB(readOnly bool, fn func(db *bolt.DB) error) error {
+	if readOnly {
+		if _, err := os.Stat(s.path); os.IsNotExist(err) {
+			return nil
+		}
+	} else if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建运行记录目录失败: %w", err)
+	}
+
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return fmt.Errorf("打开运行记录数据库失败: %w", err)
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+func (s *boltStore) Add(rec *Record) error {
+	return s.withDB(false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			b, err := tx.CreateBucketIfNotExists(runsBucket)
+			if err != nil {
+				return err
+			}
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			rec.ID = id
+
+			raw, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			return b.Put(itob(id), raw)
+		})
+	})
+}
+
+func (s *boltStore) List(q Query) ([]Record, error) {
+	var records []Record
+	err := s.withDB(true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket(runsBucket)
+			if b == nil {
+				return nil
+			}
+
+			c := b.Cursor()
+			for k, v := c.Last(); k != nil; k, v = c.Prev() {
+				var rec Record
+				if err := json.Unmarshal(v, &rec); err != nil {
+					return fmt.Errorf("解析运行记录 %d 失败: %w", btoi(k), err)
+				}
+				if !q.match(&rec) {
+					continue
+				}
+				records = append(records, rec)
+				if q.Limit > 0 && len(records) >= q.Limit {
+					break
+				}
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+func (s *boltStore) Prune(before time.Time) error {
+	return s.withDB(false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(runsBucket)
+			if b == nil {
+				return nil
+			}
+
+			// 记录按写入顺序排列，遇到第一条未过期的记录即可停止。
+			// 无法解析的记录无从判断是否过期，保留下来以便排查
+			var expired [][]byte
+			c := b.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				var rec Record
+				if err := json.Unmarshal(v, &rec); err != nil {
+					logger.GetLogger().Warn("跳过无法解析的运行记录", zap.Uint64("id", btoi(k)), zap.Error(err))
+					continue
+				}
+				if !rec.StartedAt.Before(before) {
+					break
+				}
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			for _, k := range expired {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func (s *boltStore) Close() error {
+	return nil
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func btoi(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
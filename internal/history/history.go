@@ -0,0 +1,188 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+)
+
+// 存储后端
+const (
+	BackendBolt   = "bolt"   // 嵌入式 bbolt 数据库
+	BackendMemory = "memory" // 仅保存在内存中，进程退出后丢失
+)
+
+// DefaultPath 是未配置 history.path 时 bolt 数据库的路径
+const DefaultPath = "data/history.db"
+
+// Record 是一次签到运行的完整记录
+type Record struct {
+	ID              uint64    `json:"id"`
+	Account         string    `json:"account"`
+	Name            string    `json:"name,omitempty"` // 学生信息中的真实姓名
+	Schedule        string    `json:"schedule"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	Stage           string    `json:"stage"`
+	TaskType        string    `json:"task_type"`
+	Strategy        string    `json:"strategy,omitempty"`
+	SigninID        int       `json:"signin_id"`
+	BatchNo         int       `json:"batch_no"`
+	TaskIDs         []int     `json:"task_ids"`
+	BatchNos        []int     `json:"batch_nos"`
+	CaptchaAttempts int       `json:"captcha_attempts"`
+	Solver          string    `json:"solver"`
+	Lng             float64   `json:"lng"`
+	Lat             float64   `json:"lat"`
+	Success         bool      `json:"success"`
+	ErrorClass      string    `json:"error_class,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// Query 是查询运行记录的条件，零值字段表示不过滤
+type Query struct {
+	Account  string
+	Since    time.Time
+	Until    time.Time
+	Success  *bool
+	TaskType string
+	Limit    int // 最多返回的记录数，0 表示不限制
+}
+
+// Store 是运行记录存储的接口
+type Store interface {
+	// Add 保存一条记录，并为其分配 ID
+	Add(rec *Record) error
+	// List 按开始时间从新到旧返回符合条件的记录
+	List(q Query) ([]Record, error)
+	// Prune 删除开始时间早于 before 的记录
+	Prune(before time.Time) error
+	Close() error
+}
+
+// ErrReadOnly 表示以只读方式打开的运行记录存储不能写入
+var ErrReadOnly = errors.New("运行记录以只读方式打开")
+
+// Open 根据配置打开运行记录存储，打开时和每次写入后都会按保留时长清理旧记录
+func Open(cfg config.HistoryConfig) (Store, error) {
+	store, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Retention <= 0 {
+		return store, nil
+	}
+	rs := &retainedStore{Store: store, retention: cfg.Retention}
+	if err := rs.prune(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// OpenReadOnly 以只读方式打开运行记录存储，不清理旧记录，Add 和 Prune 返回 ErrReadOnly
+func OpenReadOnly(cfg config.HistoryConfig) (Store, error) {
+	store, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return readOnlyStore{Store: store}, nil
+}
+
+func open(cfg config.HistoryConfig) (Store, error) {
+	switch cfg.Backend {
+	case BackendBolt, "":
+		path := cfg.Path
+		if path == "" {
+			path = DefaultPath
+		}
+		return newBoltStore(path), nil
+	case BackendMemory:
+		return newMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("history: 未知的存储后端 %q", cfg.Backend)
+	}
+}
+
+// readOnlyStore 只允许查询，bolt 后端查询时以只读方式打开数据库
+type readOnlyStore struct {
+	Store
+}
+
+func (readOnlyStore) Add(*Record) error     { return ErrReadOnly }
+func (readOnlyStore) Prune(time.Time) error { return ErrReadOnly }
+
+// retainedStore 在每次写入后清理超过保留时长的记录，长期运行的守护进程也能按时清理
+type retainedStore struct {
+	Store
+	retention time.Duration
+}
+
+func (s *retainedStore) Add(rec *Record) error {
+	if err := s.Store.Add(rec); err != nil {
+		return err
+	}
+	return s.prune()
+}
+
+func (s *retainedStore) prune() error {
+	if err := s.Store.Prune(time.Now().Add(-s.retention)); err != nil {
+		return fmt.Errorf("清理过期运行记录失败: %w", err)
+	}
+	return nil
+}
+
+// FromResult 将签到结果转换为运行记录
+func FromResult(r *signin.Result) *Record {
+	rec := &Record{
+		Account:         r.Account,
+		Name:            r.Name,
+		Schedule:        r.Schedule,
+		StartedAt:       r.StartedAt,
+		FinishedAt:      r.FinishedAt,
+		Stage:           r.Stage,
+		TaskType:        r.TaskType,
+		Strategy:        r.Strategy,
+		SigninID:        r.SigninID,
+		BatchNo:         r.BatchNo,
+		TaskIDs:         r.TaskIDs,
+		BatchNos:        r.BatchNos,
+		CaptchaAttempts: r.CaptchaAttempts,
+		Solver:          r.Solver,
+		Lng:             r.Lng,
+		Lat:             r.Lat,
+		Success:         r.Success(),
+		ErrorClass:      r.ErrorClass,
+	}
+	if r.Err != nil {
+		rec.Error = r.Err.Error()
+	}
+	return rec
+}
+
+// DisplayName 返回带有真实姓名的账号，如 "20230001 (张三)"
+func (rec Record) DisplayName() string {
+	if rec.Name == "" {
+		return rec.Account
+	}
+	return rec.Account + " (" + rec.Name + ")"
+}
+
+// match 判断记录是否满足查询条件
+func (q Query) match(rec *Record) bool {
+	switch {
+	case q.Account != "" && rec.Account != q.Account:
+		return false
+	case !q.Since.IsZero() && rec.StartedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !rec.StartedAt.Before(q.Until):
+		return false
+	case q.Success != nil && rec.Success != *q.Success:
+		return false
+	case q.TaskType != "" && rec.TaskType != q.TaskType:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"zhxg-signin/internal/config"
+)
+
+func TestOpenDefaultPath(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// 没有 history 配置段的旧配置文件仍可使用
+	store, err := Open(config.HistoryConfig{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := store.Add(&Record{Account: "20230001", StartedAt: time.Now()}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, DefaultPath)); err != nil {
+		t.Errorf("记录没有写入 %s: %v", DefaultPath, err)
+	}
+}
+
+func TestRetentionOnAdd(t *testing.T) {
+	backends := map[string]config.HistoryConfig{
+		BackendMemory: {Backend: BackendMemory, Retention: time.Hour},
+		BackendBolt:   {Backend: BackendBolt, Path: filepath.Join(t.TempDir(), "history.db"), Retention: time.Hour},
+	}
+	for name, cfg := range backends {
+		t.Run(name, func(t *testing.T) {
+			store, err := Open(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := time.Now()
+			// 运行期间写入的记录也会在之后的写入中被清理
+			for _, at := range []time.Time{now.Add(-2 * time.Hour), now.Add(-30 * time.Minute), now} {
+				if err := store.Add(&Record{Account: "20230001", StartedAt: at}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, err := store.List(Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 {
+				t.Fatalf("got %d records, want 2", len(records))
+			}
+			for _, rec := range records {
+				if rec.StartedAt.Before(now.Add(-time.Hour)) {
+					t.Errorf("expired record %d was kept", rec.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenReadOnlyDoesNotPrune(t *testing.T) {
+	cfg := config.HistoryConfig{Path: filepath.Join(t.TempDir(), "history.db"), Retention: time.Hour}
+	old := time.Now().Add(-2 * time.Hour)
+
+	// 不设置保留时长写入一条过期记录
+	store, err := Open(config.HistoryConfig{Path: cfg.Path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(&Record{Account: "20230001", StartedAt: old}); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := OpenReadOnly(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ro.List(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("got %d records, want the expired record to be kept", len(records))
+	}
+	if err := ro.Add(&Record{Account: "20230001", StartedAt: time.Now()}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Add error = %v, want ErrReadOnly", err)
+	}
+	if err := ro.Prune(time.Now()); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Prune error = %v, want ErrReadOnly", err)
+	}
+}
+
+// 无法解析的记录不会被当作过期记录删除
+func TestBoltPruneKeepsCorruptRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store := newBoltStore(path)
+	old := time.Now().Add(-2 * time.Hour)
+	if err := store.Add(&Record{Account: "20230001", StartedAt: old}); err != nil {
+		t.Fatal(err)
+	}
+	err := store.withDB(false, func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(runsBucket)
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			return b.Put(itob(id), []byte("{not json"))
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(&Record{Account: "20230001", StartedAt: old}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Prune(time.Now()); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	var keys []uint64
+	err = store.withDB(true, func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			return tx.Bucket(runsBucket).ForEach(func(k, v []byte) error {
+				keys = append(keys, btoi(k))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != 2 {
+		t.Errorf("records left after Prune: %v, want only the corrupt record 2", keys)
+	}
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryStore 将运行记录保存在内存中
+type memoryStore struct {
+	mu      sync.Mutex
+	records []Record
+	nextID  uint64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) Add(rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	rec.ID = s.nextID
+	s.records = append(s.records, *rec)
+	return nil
+}
+
+func (s *memoryStore) List(q Query) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []Record
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if !q.match(&s.records[i]) {
+			continue
+		}
+		records = append(records, s.records[i])
+		if q.Limit > 0 && len(records) >= q.Limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+func (s *memoryStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.records[:0]
+	for _, rec := range s.records {
+		if !rec.StartedAt.Before(before) {
+			kept = append(kept, rec)
+		}
+	}
+	s.records = kept
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
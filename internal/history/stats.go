@@ -0,0 +1,84 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// AccountStats 是单个账号的签到统计
+type AccountStats struct {
+	Account     string    `json:"account"`
+	Total       int       `json:"total"`
+	Succeeded   int       `json:"succeeded"`
+	Streak      int       `json:"streak"` // 截至最近一次运行的连续签到成功天数
+	LastSuccess time.Time `json:"last_success"`
+}
+
+// SuccessRate 返回成功率，没有记录时返回 0
+func (s AccountStats) SuccessRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Succeeded) / float64(s.Total)
+}
+
+// dayResult 是账号某一天的运行结果，当天有一次运行成功即视为成功
+type dayResult struct {
+	day string
+	ok  bool
+}
+
+// Stats 按 q 中的账号、时间和任务类型统计 store 中的记录，连续天数按 loc 中的日期计算。
+// 统计反映该范围内的全部运行，不受 q 的 Success 和 Limit 影响
+func Stats(store Store, q Query, loc *time.Location) ([]AccountStats, error) {
+	q.Success, q.Limit = nil, 0
+	records, err := store.List(q)
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(records, loc), nil
+}
+
+// Summarize 按账号统计记录，records 应按时间从新到旧排列。
+// 连续成功天数按 loc（通常为 scheduler.timezone）中的日期计算，没有运行记录的日期（如节假日）不会中断连续天数
+func Summarize(records []Record, loc *time.Location) []AccountStats {
+	byAccount := make(map[string]*AccountStats)
+	days := make(map[string][]dayResult) // 按日期从新到旧排列
+	for _, rec := range records {
+		st, ok := byAccount[rec.Account]
+		if !ok {
+			st = &AccountStats{Account: rec.Account}
+			byAccount[rec.Account] = st
+		}
+
+		st.Total++
+		if rec.Success {
+			st.Succeeded++
+			if rec.StartedAt.After(st.LastSuccess) {
+				st.LastSuccess = rec.StartedAt
+			}
+		}
+
+		day := rec.StartedAt.In(loc).Format("2006-01-02")
+		list := days[rec.Account]
+		if n := len(list); n > 0 && list[n-1].day == day {
+			list[n-1].ok = list[n-1].ok || rec.Success
+		} else {
+			list = append(list, dayResult{day: day, ok: rec.Success})
+		}
+		days[rec.Account] = list
+	}
+
+	stats := make([]AccountStats, 0, len(byAccount))
+	for account, st := range byAccount {
+		for _, d := range days[account] {
+			if !d.ok {
+				break
+			}
+			st.Streak++
+		}
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Account < stats[j].Account })
+	return stats
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeStreakCountsDays(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2025, 7, d, hour, 0, 0, 0, time.Local) }
+	run := func(at time.Time, ok bool) Record { return Record{Account: "20230001", StartedAt: at, Success: ok} }
+
+	// 按时间从新到旧排列
+	records := []Record{
+		run(day(18, 8), true),
+		run(day(17, 14), true),
+		run(day(17, 8), false), // 当天重试成功，不中断
+		run(day(16, 8), true),
+		run(day(16, 7), true),
+		// 7 月 15 日没有运行记录，不中断
+		run(day(14, 8), true),
+		run(day(13, 8), false),
+		run(day(12, 8), true),
+	}
+
+	stats := Summarize(records, time.Local)
+	if len(stats) != 1 {
+		t.Fatalf("got %d accounts, want 1", len(stats))
+	}
+	st := stats[0]
+	if st.Streak != 4 {
+		t.Errorf("Streak = %d, want 4 days", st.Streak)
+	}
+	if st.Total != 8 || st.Succeeded != 6 {
+		t.Errorf("Total, Succeeded = %d, %d, want 8, 6", st.Total, st.Succeeded)
+	}
+	if !st.LastSuccess.Equal(day(18, 8)) {
+		t.Errorf("LastSuccess = %v", st.LastSuccess)
+	}
+
+	// 最近一天全部失败时连续天数为 0
+	failed := append([]Record{run(day(19, 8), false)}, records...)
+	if got := Summarize(failed, time.Local)[0].Streak; got != 0 {
+		t.Errorf("Streak = %d after a failed day, want 0", got)
+	}
+}
+
+// 统计不受 Success 和 Limit 的影响，但仍按账号过滤
+func TestStatsIgnoresStatusAndLimit(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Now()
+	for i, ok := range []bool{true, false, true, true} {
+		if err := store.Add(&Record{Account: "20230001", StartedAt: now.Add(time.Duration(i) * time.Minute), Success: ok}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Add(&Record{Account: "20230002", StartedAt: now, Success: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := false
+	stats, err := Stats(store, Query{Account: "20230001", Success: &failed, Limit: 1}, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Total != 4 || stats[0].Succeeded != 3 {
+		t.Errorf("stats = %+v, want 4 runs with 3 successes for 20230001", stats)
+	}
+}
+
+// 同一时刻在不同时区可能属于不同的日期
+func TestSummarizeUsesLocation(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 北京时间 7 月 18 日 07:00 和 7 月 17 日 08:00，在 UTC 中都是 7 月 17 日
+	records := []Record{
+		{Account: "20230001", StartedAt: time.Date(2025, 7, 17, 23, 0, 0, 0, time.UTC), Success: true},
+		{Account: "20230001", StartedAt: time.Date(2025, 7, 17, 0, 0, 0, 0, time.UTC), Success: false},
+	}
+	if got := Summarize(records, shanghai)[0].Streak; got != 1 {
+		t.Errorf("Streak in Asia/Shanghai = %d, want 1", got)
+	}
+	if got := Summarize(records, time.UTC)[0].Streak; got != 1 {
+		t.Errorf("Streak in UTC = %d, want 1", got)
+	}
+	records[1].Success, records[0].Success = true, false
+	if got := Summarize(records, shanghai)[0].Streak; got != 0 {
+		t.Errorf("Streak in Asia/Shanghai = %d, want 0 after a failed day", got)
+	}
+	if got := Summarize(records, time.UTC)[0].Streak; got != 1 {
+		t.Errorf("Streak in UTC = %d, want 1", got)
+	}
+}
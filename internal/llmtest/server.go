@@ -0,0 +1,240 @@
+// Package llmtest 提供一个兼容 OpenAI chat completions 接口的模拟 LLM 服务，用于在不消耗 token 的情况下测试验证码识别。
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"zhxg-signin/internal/captcha"
+)
+
+// Reply 描述模拟服务对一次请求的回复
+type Reply struct {
+	Status     int           // HTTP 状态码，0 表示 200
+	Content    string        // choices[0].message.content
+	NoChoices  bool          // 返回空的 choices 列表
+	RetryAfter string        // Retry-After 响应头
+	Delay      time.Duration // 回复前等待的时长，用于模拟慢响应
+	Usage      captcha.Usage
+
+	// Solve 不为 nil 时根据验证码图片计算答案并忽略 Content，
+	// 与 wisestutest.Server.AnswerImage 搭配可正确识别模拟服务的验证码
+	Solve func(image string) (int, bool)
+}
+
+var defaultUsage = captcha.Usage{PromptTokens: 850, CompletionTokens: 24}
+
+// JSON 返回按提示词要求格式化的正确回复
+func JSON(result int) Reply {
+	return Reply{Content: answer(result), Usage: defaultUsage}
+}
+
+// FencedJSON 返回包裹在 Markdown 代码块中的回复
+func FencedJSON(result int) Reply {
+	return Reply{Content: "```json\n" + answer(result) + "\n```", Usage: defaultUsage}
+}
+
+// WrongArithmetic 返回识别出算式但计算错误的回复，result 应为错误的答案
+func WrongArithmetic(result int) Reply {
+	return JSON(result)
+}
+
+// EmptyChoices 返回 choices 为空的回复
+func EmptyChoices() Reply {
+	return Reply{NoChoices: true}
+}
+
+// Unauthorized 返回 401，模拟 API Key 无效
+func Unauthorized() Reply {
+	return Reply{Status: http.StatusUnauthorized}
+}
+
+// NotFound 返回 404，模拟 endpoint 或模型名称错误
+func NotFound() Reply {
+	return Reply{Status: http.StatusNotFound}
+}
+
+// RateLimited 返回 429 并携带 Retry-After 响应头
+func RateLimited(retryAfter time.Duration) Reply {
+	return Reply{Status: http.StatusTooManyRequests, RetryAfter: fmt.Sprint(int(retryAfter.Seconds()))}
+}
+
+// Slow 在 delay 之后返回 r
+func Slow(delay time.Duration, r Reply) Reply {
+	r.Delay = delay
+	return r
+}
+
+// Solver 返回根据验证码图片计算答案的回复
+func Solver(solve func(image string) (int, bool)) Reply {
+	return Reply{Solve: solve, Usage: defaultUsage}
+}
+
+func answer(result int) string {
+	return fmt.Sprintf(`{"expression": "?", "result": %d, "error": null}`, result)
+}
+
+// Request 是模拟服务收到的一次请求
+type Request struct {
+	Model         string
+	Authorization string
+	Image         string // 验证码图片的 base64 编码，不含 data URL 前缀
+}
+
+// Server 是模拟的 LLM 服务，按顺序使用预设的回复，用完后重复最后一个
+type Server struct {
+	mu       sync.Mutex
+	replies  []Reply
+	requests []Request
+}
+
+// New 创建一个模拟 LLM 服务，可直接作为 http.Handler 使用
+func New(replies ...Reply) *Server {
+	if len(replies) == 0 {
+		replies = []Reply{JSON(0)}
+	}
+	return &Server{replies: replies}
+}
+
+// NewServer 创建模拟 LLM 服务并在本地随机端口启动，endpoint 为 URL + "/v1/chat/completions"
+func NewServer(replies ...Reply) (*Server, *httptest.Server) {
+	s := New(replies...)
+	return s, httptest.NewServer(s)
+}
+
+// Requests 返回收到的所有请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP 处理 chat completions 请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		writeError(w, http.StatusNotFound, "not_found", "Invalid URL ("+r.Method+" "+r.URL.Path+")")
+		return
+	}
+
+	var body struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Content []struct {
+				Type     string `json:"type"`
+				ImageURL struct {
+					URL string `json:"url"`
+				} `json:"image_url"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "请求体不是有效的 JSON")
+		return
+	}
+
+	// 与 OpenAI 一致，messages 为空时返回 400 且不消耗预设的回复，用于就绪检查
+	if len(body.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "'messages' must contain at least one message.")
+		return
+	}
+
+	req := Request{Model: body.Model, Authorization: r.Header.Get("Authorization")}
+	for _, m := range body.Messages {
+		for _, c := range m.Content {
+			if c.Type == "image_url" {
+				_, req.Image, _ = strings.Cut(c.ImageURL.URL, "base64,")
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	s.mu.Unlock()
+
+	if reply.Delay > 0 {
+		select {
+		case <-time.After(reply.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch reply.Status {
+	case 0, http.StatusOK:
+	case http.StatusUnauthorized:
+		writeError(w, reply.Status, "invalid_api_key", "Incorrect API key provided.")
+		return
+	case http.StatusNotFound:
+		writeError(w, reply.Status, "model_not_found", fmt.Sprintf("The model `%s` does not exist.", body.Model))
+		return
+	case http.StatusTooManyRequests:
+		if reply.RetryAfter != "" {
+			w.Header().Set("Retry-After", reply.RetryAfter)
+		}
+		writeError(w, reply.Status, "rate_limit_exceeded", "Rate limit reached for requests.")
+		return
+	default:
+		writeError(w, reply.Status, "server_error", http.StatusText(reply.Status))
+		return
+	}
+
+	content := reply.Content
+	if reply.Solve != nil {
+		result, ok := reply.Solve(req.Image)
+		if !ok {
+			content = `{"expression": null, "result": null, "error": "无法识别图片中的算式"}`
+		} else {
+			content = answer(result)
+		}
+	}
+
+	type choice struct {
+		Index   int `json:"index"`
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	}
+	choices := []choice{}
+	if !reply.NoChoices {
+		c := choice{FinishReason: "stop"}
+		c.Message.Role = "assistant"
+		c.Message.Content = content
+		choices = append(choices, c)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      "chatcmpl-mock",
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   body.Model,
+		"choices": choices,
+		"usage": map[string]int{
+			"prompt_tokens":     reply.Usage.PromptTokens,
+			"completion_tokens": reply.Usage.CompletionTokens,
+			"total_tokens":      reply.Usage.PromptTokens + reply.Usage.CompletionTokens,
+		},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": "invalid_request_error", "code": code},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
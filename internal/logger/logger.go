@@ -51,8 +51,11 @@ func InitLogger(cfg config.LoggingConfig) {
 	log = zap.New(core, zap.AddCaller(), zap.Development())
 }
 
-// GetLogger 获取全局日志记录器
+// GetLogger 获取全局日志记录器，未初始化时（如单元测试中）返回不输出任何内容的记录器
 func GetLogger() *zap.Logger {
+	if log == nil {
+		return zap.NewNop()
+	}
 	return log
 }
 
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "zhxg_signin"
+
+// registry 是本程序专用的指标注册表
+var registry = prometheus.NewRegistry()
+
+var (
+	runsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "runs_total",
+		Help:      "签到运行次数，按结果和错误分类统计",
+	}, []string{"account", "result", "error_class"})
+
+	loginAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "登录尝试次数",
+	}, []string{"account", "result"})
+
+	captchaSolvesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "captcha_solves_total",
+		Help:      "验证码识别次数，outcome 为 error（识别失败）、accepted（登录通过）或 rejected（登录未通过）",
+	}, []string{"account", "solver", "outcome"})
+
+	llmDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "LLM API 请求耗时，status 为 ok、transport_error、invalid_response 或 HTTP 状态码",
+		Buckets:   []float64{0.5, 1, 2, 4, 8, 15, 30},
+	}, []string{"account", "model", "status"})
+
+	llmTokensTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "LLM API 消耗的 token 数，type 为 prompt 或 completion",
+	}, []string{"account", "model", "type"})
+
+	apiDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "智慧学工 API 请求耗时，按 action 统计",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"account", "action", "result"})
+
+	pendingTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pending_tasks",
+		Help:      "最近一次查询到的未签到任务数",
+	}, []string{"account"})
+
+	lastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_success_timestamp_seconds",
+		Help:      "最近一次成功提交签到的 Unix 时间戳",
+	}, []string{"account"})
+)
+
+func init() {
+	registry.MustRegister(
+		runsTotal, loginAttemptsTotal, captchaSolvesTotal,
+		llmDuration, llmTokensTotal, apiDuration,
+		pendingTasks, lastSuccess,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler 返回 /metrics 的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRun 记录一次签到运行的结果，submitted 表示本次运行实际完成了签到
+func ObserveRun(account string, success, submitted bool, errorClass string, at time.Time) {
+	runsTotal.WithLabelValues(account, result(success), errorClass).Inc()
+	if submitted {
+		lastSuccess.WithLabelValues(account).Set(float64(at.Unix()))
+	}
+}
+
+// ObserveLogin 记录一次登录尝试
+func ObserveLogin(account string, success bool) {
+	loginAttemptsTotal.WithLabelValues(account, result(success)).Inc()
+}
+
+// ObserveCaptcha 记录一次验证码识别的结果
+func ObserveCaptcha(account, solver, outcome string) {
+	captchaSolvesTotal.WithLabelValues(account, solver, outcome).Inc()
+}
+
+// ObserveLLM 记录一次 LLM API 请求的耗时、结果和 token 用量，请求失败时 token 用量为 0
+func ObserveLLM(account, model, status string, d time.Duration, promptTokens, completionTokens int) {
+	llmDuration.WithLabelValues(account, model, status).Observe(d.Seconds())
+	llmTokensTotal.WithLabelValues(account, model, "prompt").Add(float64(promptTokens))
+	llmTokensTotal.WithLabelValues(account, model, "completion").Add(float64(completionTokens))
+}
+
+// ObserveAPI 记录一次智慧学工 API 请求的耗时
+func ObserveAPI(account, action string, d time.Duration, err error) {
+	apiDuration.WithLabelValues(account, action, result(err == nil)).Observe(d.Seconds())
+}
+
+// SetPendingTasks 记录最近一次查询到的未签到任务数
+func SetPendingTasks(account string, n int) {
+	pendingTasks.WithLabelValues(account).Set(float64(n))
+}
+
+func result(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
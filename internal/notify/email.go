@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/signin"
+)
+
+// 邮件连接的加密方式
+const (
+	SecurityStartTLS = "starttls" // 明文连接后通过 STARTTLS 升级，通常为 587 端口
+	SecurityTLS      = "tls"      // 隐式 TLS，通常为 465 端口
+	SecurityNone     = "none"     // 不加密，仅用于本地测试
+)
+
+// Email 通过 SMTP 发送签到结果和每日汇总
+type Email struct {
+	cfg config.EmailConfig
+}
+
+// NewEmail 创建一个新的邮件通知渠道
+func NewEmail(cfg config.EmailConfig) (*Email, error) {
+	if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("email: host、from 和 to 不能为空")
+	}
+	switch cfg.Security {
+	case "":
+		cfg.Security = SecurityStartTLS
+	case SecurityStartTLS, SecurityTLS, SecurityNone:
+	default:
+		return nil, fmt.Errorf("email: 未知的加密方式 %q", cfg.Security)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.Security == SecurityTLS {
+			cfg.Port = 465
+		}
+	}
+	return &Email{cfg: cfg}, nil
+}
+
+// Name 返回渠道名称
+func (e *Email) Name() string {
+	return "email"
+}
+
+// Notify 发送单次签到结果
+func (e *Email) Notify(r *signin.Result) error {
+	return e.send(Title(r), Text(r))
+}
+
+// SendDigest 发送指定日期所有账号的签到汇总，accounts 中没有记录的账号也会列出
+func (e *Email) SendDigest(day time.Time, accounts []string, runs []history.Record) error {
+	return e.send(fmt.Sprintf("签到日报 %s", day.Format("2006-01-02")), DigestText(accounts, runs))
+}
+
+// DigestText 返回按账号分组的签到汇总文本，runs 应按时间从新到旧排列
+func DigestText(accounts []string, runs []history.Record) string {
+	byAccount := make(map[string][]history.Record)
+	for _, acc := range accounts {
+		byAccount[acc] = nil
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		byAccount[runs[i].Account] = append(byAccount[runs[i].Account], runs[i])
+	}
+
+	names := make([]string, 0, len(byAccount))
+	for name := range byAccount {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		list := byAccount[name]
+		status := "未签到"
+		for _, run := range list {
+			if run.Success {
+				status = "已签到"
+			}
+		}
+		if len(list) == 0 {
+			status = "今日无运行记录"
+		}
+		fmt.Fprintf(&b, "账号 %s: %s\n", name, status)
+
+		for _, run := range list {
+			result := "成功"
+			if !run.Success {
+				result = fmt.Sprintf("失败 (%s): %s", run.ErrorClass, run.Error)
+			}
+			task := run.TaskType
+			if task == "" {
+				task = "-"
+			}
+			fmt.Fprintf(&b, "  %s  来源: %s  任务: %s  %s\n", run.StartedAt.Format("15:04:05"), run.Schedule, task, result)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (e *Email) send(subject, body string) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: e.cfg.Host, InsecureSkipVerify: e.cfg.InsecureSkipVerify}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	if e.cfg.Security == SecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("创建 SMTP 客户端失败: %w", err)
+	}
+	defer c.Close()
+
+	if e.cfg.Security == SecurityStartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(e.cfg.From)
+	if err != nil {
+		return fmt.Errorf("无效的发件人地址: %w", err)
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range e.cfg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("无效的收件人地址: %w", err)
+		}
+		if err := c.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.message(subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *Email) message(subject, body string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
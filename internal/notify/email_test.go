@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/signin"
+)
+
+// smtpMessage 是本地 SMTP 替身收到的一封邮件
+type smtpMessage struct {
+	auth string // AUTH PLAIN 的凭据，base64 解码后的内容
+	from string
+	to   []string
+	data string
+}
+
+// startSMTP 启动只接受一个连接的 SMTP 替身，邮件在连接结束后写入返回的 channel
+func startSMTP(t *testing.T) (host string, port int, got <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		var msg smtpMessage
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				cred, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+				msg.auth = string(cred)
+				reply("235 2.7.0 Authentication successful")
+			case "MAIL":
+				msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+				reply("250 OK")
+			case "RCPT":
+				msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				msg.data = b.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				ch <- msg
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+// readMessage 解析邮件并返回解码后的主题和正文
+func readMessage(t *testing.T, data string) (subject, body string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	subject, err = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, m.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subject, string(raw)
+}
+
+func newTestEmail(t *testing.T, host string, port int) *Email {
+	t.Helper()
+	e, err := NewEmail(config.EmailConfig{
+		Host:     host,
+		Port:     port,
+		Security: SecurityNone,
+		Username: "bot",
+		Password: "secret",
+		From:     "zhxg-signin <bot@example.com>",
+		To:       []string{"you@example.com", "Other <other@example.com>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEmailNotify(t *testing.T) {
+	host, port, got := startSMTP(t)
+	e := newTestEmail(t, host, port)
+
+	res := &signin.Result{
+		Account:   "20230001",
+		Name:      "张三",
+		TaskType:  "实习",
+		StartedAt: time.Date(2025, 7, 14, 8, 3, 0, 0, time.Local),
+		Err:       errors.New("签到已结束"),
+	}
+	if err := e.Notify(res); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	msg := <-got
+	if msg.auth != "\x00bot\x00secret" {
+		t.Errorf("auth = %q", msg.auth)
+	}
+	if msg.from != "bot@example.com" {
+		t.Errorf("MAIL FROM = %q", msg.from)
+	}
+	if strings.Join(msg.to, ",") != "you@example.com,other@example.com" {
+		t.Errorf("RCPT TO = %v", msg.to)
+	}
+
+	subject, body := readMessage(t, msg.data)
+	if subject != Title(res) {
+		t.Errorf("subject = %q, want %q", subject, Title(res))
+	}
+	for _, want := range []string{"任务: 实习", "错误: 签到已结束"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	host, port, got := startSMTP(t)
+	e := newTestEmail(t, host, port)
+
+	day := time.Date(2025, 7, 14, 0, 0, 0, 0, time.Local)
+	runs := []history.Record{
+		{Account: "20230001", Success: true, Schedule: "morning", TaskType: "实习", StartedAt: day.Add(8 * time.Hour)},
+	}
+	if err := e.SendDigest(day, []string{"20230001", "20230002"}, runs); err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+
+	subject, body := readMessage(t, (<-got).data)
+	if subject != "签到日报 2025-07-14" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{"账号 20230001: 已签到", "账号 20230002: 今日无运行记录"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEmailConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	e := newTestEmail(t, "127.0.0.1", port)
+	if err := e.Notify(&signin.Result{Account: "20230001"}); err == nil {
+		t.Error("Notify succeeded without an SMTP server")
+	}
+}
@@ -0,0 +1,319 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+)
+
+const (
+	defaultServerChanEndpoint = "https://sctapi.ftqq.com"
+	defaultBarkServer         = "https://api.day.app"
+)
+
+// Markdown 返回签到结果的 Markdown 摘要，包含任务类型、批次和结果
+func Markdown(r *signin.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**账号**: %s\n\n", r.DisplayName())
+	if r.TaskType != "" {
+		fmt.Fprintf(&b, "**任务类型**: %s\n\n", r.TaskType)
+		fmt.Fprintf(&b, "**批次**: %d\n\n", r.BatchNo)
+	} else {
+		b.WriteString("**任务类型**: 没有需要签到的任务\n\n")
+	}
+	fmt.Fprintf(&b, "**位置**: %.6f, %.6f\n\n", r.Lng, r.Lat)
+	if r.Success() {
+		b.WriteString("**结果**: ✅ 成功\n\n")
+	} else {
+		fmt.Fprintf(&b, "**结果**: ❌ 失败\n\n**错误**: %v\n\n", r.Err)
+	}
+	fmt.Fprintf(&b, "**时间**: %s", r.StartedAt.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
+
+func newIMClient() *resty.Client {
+	return resty.New().SetTimeout(15 * time.Second)
+}
+
+// DingTalk 是钉钉群自定义机器人
+type DingTalk struct {
+	cfg    config.RobotConfig
+	client *resty.Client
+}
+
+// NewDingTalk 创建一个新的钉钉机器人通知渠道
+func NewDingTalk(cfg config.RobotConfig) (*DingTalk, error) {
+	if cfg.Webhook == "" {
+		return nil, fmt.Errorf("dingtalk: 未配置 webhook")
+	}
+	return &DingTalk{cfg: cfg, client: newIMClient()}, nil
+}
+
+// Name 返回渠道名称
+func (d *DingTalk) Name() string {
+	return "dingtalk"
+}
+
+// Notify 以 Markdown 消息发送签到结果
+func (d *DingTalk) Notify(r *signin.Result) error {
+	target := d.cfg.Webhook
+	if d.cfg.Secret != "" {
+		// 加签：HMAC-SHA256(secret, timestamp + "\n" + secret) 后 Base64 编码
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(d.cfg.Secret))
+		mac.Write([]byte(ts + "\n" + d.cfg.Secret))
+		sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		target = appendQuery(target, url.Values{"timestamp": {ts}, "sign": {sign}})
+	}
+
+	title := Title(r)
+	body := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": title,
+			"text":  "### " + title + "\n\n" + Markdown(r),
+		},
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := postJSON(d.client, target, body, &result); err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("钉钉机器人返回错误, errcode: %d, errmsg: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// WeCom 是企业微信群机器人
+type WeCom struct {
+	cfg    config.RobotConfig
+	client *resty.Client
+}
+
+// NewWeCom 创建一个新的企业微信机器人通知渠道
+func NewWeCom(cfg config.RobotConfig) (*WeCom, error) {
+	if cfg.Webhook == "" {
+		return nil, fmt.Errorf("wecom: 未配置 webhook")
+	}
+	return &WeCom{cfg: cfg, client: newIMClient()}, nil
+}
+
+// Name 返回渠道名称
+func (w *WeCom) Name() string {
+	return "wecom"
+}
+
+// Notify 以 Markdown 消息发送签到结果
+func (w *WeCom) Notify(r *signin.Result) error {
+	color := "info"
+	if !r.Success() {
+		color = "warning"
+	}
+	body := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": fmt.Sprintf("### <font color=\"%s\">%s</font>\n%s", color, Title(r), Markdown(r)),
+		},
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := postJSON(w.client, w.cfg.Webhook, body, &result); err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("企业微信机器人返回错误, errcode: %d, errmsg: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// Feishu 是飞书/Lark 群自定义机器人
+type Feishu struct {
+	cfg    config.RobotConfig
+	client *resty.Client
+}
+
+// NewFeishu 创建一个新的飞书机器人通知渠道
+func NewFeishu(cfg config.RobotConfig) (*Feishu, error) {
+	if cfg.Webhook == "" {
+		return nil, fmt.Errorf("feishu: 未配置 webhook")
+	}
+	return &Feishu{cfg: cfg, client: newIMClient()}, nil
+}
+
+// Name 返回渠道名称
+func (f *Feishu) Name() string {
+	return "feishu"
+}
+
+// Notify 以消息卡片发送签到结果
+func (f *Feishu) Notify(r *signin.Result) error {
+	template := "green"
+	if !r.Success() {
+		template = "red"
+	}
+	body := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": Title(r)},
+				"template": template,
+			},
+			"elements": []map[string]interface{}{
+				{"tag": "div", "text": map[string]string{"tag": "lark_md", "content": Markdown(r)}},
+			},
+		},
+	}
+	if f.cfg.Secret != "" {
+		// 签名：以 timestamp + "\n" + secret 为密钥对空串做 HMAC-SHA256 后 Base64 编码
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(ts+"\n"+f.cfg.Secret))
+		body["timestamp"] = ts
+		body["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := postJSON(f.client, f.cfg.Webhook, body, &result); err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("飞书机器人返回错误, code: %d, msg: %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// ServerChan 是 Server 酱推送
+type ServerChan struct {
+	cfg    config.ServerChanConfig
+	client *resty.Client
+}
+
+// NewServerChan 创建一个新的 Server 酱通知渠道
+func NewServerChan(cfg config.ServerChanConfig) (*ServerChan, error) {
+	if cfg.SendKey == "" {
+		return nil, fmt.Errorf("serverchan: 未配置 sendkey")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaultServerChanEndpoint
+	}
+	return &ServerChan{cfg: cfg, client: newIMClient()}, nil
+}
+
+// Name 返回渠道名称
+func (s *ServerChan) Name() string {
+	return "serverchan"
+}
+
+// Notify 发送签到结果，desp 字段支持 Markdown
+func (s *ServerChan) Notify(r *signin.Result) error {
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	resp, err := s.client.R().
+		SetFormData(map[string]string{"title": Title(r), "desp": Markdown(r)}).
+		SetResult(&result).
+		Post(strings.TrimRight(s.cfg.Endpoint, "/") + "/" + s.cfg.SendKey + ".send")
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Server 酱请求失败: %s", resp.Status())
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("Server 酱返回错误, code: %d, message: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// Bark 是 iOS Bark 推送
+type Bark struct {
+	cfg    config.BarkConfig
+	client *resty.Client
+}
+
+// NewBark 创建一个新的 Bark 通知渠道
+func NewBark(cfg config.BarkConfig) (*Bark, error) {
+	if cfg.DeviceKey == "" {
+		return nil, fmt.Errorf("bark: 未配置 device_key")
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultBarkServer
+	}
+	if cfg.Group == "" {
+		cfg.Group = "zhxg-signin"
+	}
+	return &Bark{cfg: cfg, client: newIMClient()}, nil
+}
+
+// Name 返回渠道名称
+func (b *Bark) Name() string {
+	return "bark"
+}
+
+// Notify 发送签到结果，失败时使用时效性通知级别
+func (b *Bark) Notify(r *signin.Result) error {
+	body := map[string]interface{}{
+		"device_key": b.cfg.DeviceKey,
+		"title":      Title(r),
+		"body":       Text(r),
+		"group":      b.cfg.Group,
+	}
+	if !r.Success() {
+		body["level"] = "timeSensitive"
+	}
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := postJSON(b.client, strings.TrimRight(b.cfg.Server, "/")+"/push", body, &result); err != nil {
+		return err
+	}
+	if result.Code != 200 {
+		return fmt.Errorf("Bark 返回错误, code: %d, message: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// postJSON 发送 JSON 请求并将响应解析到 result
+func postJSON(client *resty.Client, target string, body, result interface{}) error {
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		SetResult(result).
+		Post(target)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("请求失败: %s", resp.Status())
+	}
+	return nil
+}
+
+func appendQuery(target string, values url.Values) string {
+	sep := "?"
+	if strings.Contains(target, "?") {
+		sep = "&"
+	}
+	return target + sep + values.Encode()
+}
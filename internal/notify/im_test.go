@@ -0,0 +1,269 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+)
+
+// captured 是假服务器收到的一次请求
+type captured struct {
+	path  string
+	query url.Values
+	ctype string
+	body  []byte
+}
+
+// jsonBody 将请求体解析为 map
+func (c captured) jsonBody(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal(c.body, &m); err != nil {
+		t.Fatalf("请求体不是 JSON: %v\n%s", err, c.body)
+	}
+	return m
+}
+
+// field 按路径读取嵌套 map 中的字符串
+func field(m map[string]interface{}, path ...string) string {
+	var v interface{} = m
+	for _, key := range path {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = obj[key]
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// checkTimestamp 检查时间戳是否在 [before, after] 之间，unit 为时间戳的单位
+func checkTimestamp(t *testing.T, ts string, before, after time.Time, unit time.Duration) {
+	t.Helper()
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		t.Fatalf("无效的时间戳 %q", ts)
+	}
+	lo, hi := before.Truncate(unit).UnixNano()/int64(unit), after.UnixNano()/int64(unit)
+	if n < lo || n > hi {
+		t.Errorf("timestamp %d not in [%d, %d]", n, lo, hi)
+	}
+}
+
+func hmacBase64(key, msg string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(msg))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestIMNotifiers(t *testing.T) {
+	failed := &signin.Result{
+		Account:   "20230001",
+		Name:      "张三",
+		TaskType:  "实习",
+		BatchNo:   20250801,
+		StartedAt: time.Date(2025, 7, 14, 8, 3, 0, 0, time.Local),
+		Err:       errors.New("签到已结束"),
+	}
+
+	tests := []struct {
+		name    string
+		respond string // 假服务器返回的 JSON
+		build   func(base string) (Notifier, error)
+		check   func(t *testing.T, req captured, before, after time.Time)
+		wantErr bool
+	}{
+		{
+			name:    "dingtalk",
+			respond: `{"errcode":0,"errmsg":"ok"}`,
+			build: func(base string) (Notifier, error) {
+				return NewDingTalk(config.RobotConfig{Webhook: base + "/robot/send?access_token=abc", Secret: "SECabc"})
+			},
+			check: func(t *testing.T, req captured, before, after time.Time) {
+				if req.path != "/robot/send" || req.query.Get("access_token") != "abc" {
+					t.Errorf("request to %s?%s", req.path, req.query.Encode())
+				}
+				ts := req.query.Get("timestamp")
+				checkTimestamp(t, ts, before, after, time.Millisecond)
+				if want := hmacBase64("SECabc", ts+"\nSECabc"); req.query.Get("sign") != want {
+					t.Errorf("sign = %q, want %q", req.query.Get("sign"), want)
+				}
+				body := req.jsonBody(t)
+				if body["msgtype"] != "markdown" || field(body, "markdown", "title") != Title(failed) {
+					t.Errorf("unexpected body %s", req.body)
+				}
+				if text := field(body, "markdown", "text"); !strings.Contains(text, "**错误**: 签到已结束") {
+					t.Errorf("markdown text = %q", text)
+				}
+			},
+		},
+		{
+			name:    "dingtalk error",
+			respond: `{"errcode":310000,"errmsg":"sign not match"}`,
+			build: func(base string) (Notifier, error) {
+				return NewDingTalk(config.RobotConfig{Webhook: base + "/robot/send?access_token=abc", Secret: "SECabc"})
+			},
+			wantErr: true,
+		},
+		{
+			name:    "feishu",
+			respond: `{"code":0,"msg":"success"}`,
+			build: func(base string) (Notifier, error) {
+				return NewFeishu(config.RobotConfig{Webhook: base + "/open-apis/bot/v2/hook/xxx", Secret: "feishu-secret"})
+			},
+			check: func(t *testing.T, req captured, before, after time.Time) {
+				body := req.jsonBody(t)
+				ts := field(body, "timestamp")
+				checkTimestamp(t, ts, before, after, time.Second)
+				// 以 timestamp + "\n" + secret 为密钥对空串签名
+				if want := hmacBase64(ts+"\nfeishu-secret", ""); field(body, "sign") != want {
+					t.Errorf("sign = %q, want %q", field(body, "sign"), want)
+				}
+				if body["msg_type"] != "interactive" ||
+					field(body, "card", "header", "template") != "red" ||
+					field(body, "card", "header", "title", "content") != Title(failed) {
+					t.Errorf("unexpected body %s", req.body)
+				}
+			},
+		},
+		{
+			name:    "feishu error",
+			respond: `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`,
+			build: func(base string) (Notifier, error) {
+				return NewFeishu(config.RobotConfig{Webhook: base + "/open-apis/bot/v2/hook/xxx", Secret: "feishu-secret"})
+			},
+			wantErr: true,
+		},
+		{
+			name:    "wecom",
+			respond: `{"errcode":0,"errmsg":"ok"}`,
+			build: func(base string) (Notifier, error) {
+				return NewWeCom(config.RobotConfig{Webhook: base + "/cgi-bin/webhook/send?key=xxx"})
+			},
+			check: func(t *testing.T, req captured, _, _ time.Time) {
+				if req.path != "/cgi-bin/webhook/send" || req.query.Get("key") != "xxx" {
+					t.Errorf("request to %s?%s", req.path, req.query.Encode())
+				}
+				body := req.jsonBody(t)
+				content := field(body, "markdown", "content")
+				if body["msgtype"] != "markdown" || !strings.HasPrefix(content, `### <font color="warning">`+Title(failed)+"</font>\n") {
+					t.Errorf("unexpected body %s", req.body)
+				}
+			},
+		},
+		{
+			name:    "serverchan",
+			respond: `{"code":0,"message":""}`,
+			build: func(base string) (Notifier, error) {
+				return NewServerChan(config.ServerChanConfig{SendKey: "SCT123", Endpoint: base})
+			},
+			check: func(t *testing.T, req captured, _, _ time.Time) {
+				if req.path != "/SCT123.send" {
+					t.Errorf("request to %s", req.path)
+				}
+				if !strings.HasPrefix(req.ctype, "application/x-www-form-urlencoded") {
+					t.Errorf("Content-Type = %q", req.ctype)
+				}
+				form, err := url.ParseQuery(string(req.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if form.Get("title") != Title(failed) || form.Get("desp") != Markdown(failed) {
+					t.Errorf("unexpected form %v", form)
+				}
+			},
+		},
+		{
+			name:    "bark",
+			respond: `{"code":200,"message":"success"}`,
+			build: func(base string) (Notifier, error) {
+				return NewBark(config.BarkConfig{Server: base + "/", DeviceKey: "device"})
+			},
+			check: func(t *testing.T, req captured, _, _ time.Time) {
+				if req.path != "/push" {
+					t.Errorf("request to %s", req.path)
+				}
+				body := req.jsonBody(t)
+				want := map[string]string{
+					"device_key": "device",
+					"title":      Title(failed),
+					"body":       Text(failed),
+					"group":      "zhxg-signin",
+					"level":      "timeSensitive",
+				}
+				for k, v := range want {
+					if field(body, k) != v {
+						t.Errorf("%s = %q, want %q", k, field(body, k), v)
+					}
+				}
+			},
+		},
+		{
+			name:    "bark error",
+			respond: `{"code":400,"message":"failed to get device token"}`,
+			build: func(base string) (Notifier, error) {
+				return NewBark(config.BarkConfig{Server: base, DeviceKey: "device"})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []captured
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = append(got, captured{path: r.URL.Path, query: r.URL.Query(), ctype: r.Header.Get("Content-Type"), body: body})
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.respond)
+			}))
+			defer srv.Close()
+
+			n, err := tt.build(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := time.Now()
+			err = n.Notify(failed)
+			after := time.Now()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != 1 {
+				t.Fatalf("server received %d requests, want 1", len(got))
+			}
+			if tt.check != nil {
+				tt.check(t, got[0], before, after)
+			}
+		})
+	}
+}
+
+func TestIMNotifierHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	n, err := NewWeCom(config.RobotConfig{Webhook: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(&signin.Result{Account: "20230001"}); err == nil {
+		t.Error("Notify succeeded on 502")
+	}
+}
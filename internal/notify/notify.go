@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+)
+
+// 通知策略
+const (
+	PolicyAlways  = "always"  // 每次运行后都通知
+	PolicyFailure = "failure" // 仅在失败时通知
+	PolicyChange  = "change"  // 仅在成功/失败状态发生变化时通知
+)
+
+// Notifier 是通知渠道需要实现的接口
+type Notifier interface {
+	// Name 返回渠道名称，用于日志
+	Name() string
+	// Notify 发送一次签到结果
+	Notify(r *signin.Result) error
+}
+
+// channel 是带有独立通知策略的渠道
+type channel struct {
+	Notifier
+	policy string
+}
+
+// Dispatcher 按通知策略将签到结果分发到各个渠道
+type Dispatcher struct {
+	policy   string
+	channels []channel
+	store    *state.Store
+	log      *zap.Logger
+}
+
+// New 根据配置创建 Dispatcher，store 用于 change 策略判断状态是否变化
+func New(cfg config.NotifyConfig, store *state.Store) (*Dispatcher, error) {
+	policy := cfg.On
+	if policy == "" {
+		policy = PolicyFailure
+	}
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	d := &Dispatcher{policy: policy, store: store, log: logger.GetLogger()}
+
+	channels := []struct {
+		enabled bool
+		on      string
+		build   func() (Notifier, error)
+	}{
+		{cfg.Webhook.Enabled, cfg.Webhook.On, func() (Notifier, error) { return NewWebhook(cfg.Webhook) }},
+		{cfg.Email.Enabled, cfg.Email.On, func() (Notifier, error) { return NewEmail(cfg.Email) }},
+		{cfg.DingTalk.Enabled, cfg.DingTalk.On, func() (Notifier, error) { return NewDingTalk(cfg.DingTalk) }},
+		{cfg.WeCom.Enabled, cfg.WeCom.On, func() (Notifier, error) { return NewWeCom(cfg.WeCom) }},
+		{cfg.Feishu.Enabled, cfg.Feishu.On, func() (Notifier, error) { return NewFeishu(cfg.Feishu) }},
+		{cfg.ServerChan.Enabled, cfg.ServerChan.On, func() (Notifier, error) { return NewServerChan(cfg.ServerChan) }},
+		{cfg.Bark.Enabled, cfg.Bark.On, func() (Notifier, error) { return NewBark(cfg.Bark) }},
+		{cfg.Telegram.Enabled, cfg.Telegram.On, func() (Notifier, error) { return NewTelegram(cfg.Telegram) }},
+	}
+	for _, ch := range channels {
+		if !ch.enabled {
+			continue
+		}
+		n, err := ch.build()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Add(n, ch.on); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Add 注册一个渠道，on 为空时使用全局通知策略
+func (d *Dispatcher) Add(n Notifier, on string) error {
+	if on == "" {
+		on = d.policy
+	}
+	if err := validatePolicy(on); err != nil {
+		return fmt.Errorf("%s: %w", n.Name(), err)
+	}
+	d.channels = append(d.channels, channel{Notifier: n, policy: on})
+	return nil
+}
+
+// Dispatch 将签到结果发送到所有符合通知策略的渠道，发送失败只记录日志
+func (d *Dispatcher) Dispatch(r *signin.Result) {
+	if d == nil {
+		return
+	}
+
+	changed := true
+	if d.store != nil {
+		if prev, known := d.store.LastStatus(r.Account); known {
+			changed = prev != r.Success()
+		}
+		if err := d.store.SetLastStatus(r.Account, r.Success()); err != nil {
+			d.log.Warn("保存签到状态失败", zap.Error(err))
+		}
+	}
+
+	for _, ch := range d.channels {
+		if !shouldNotify(ch.policy, r, changed) {
+			continue
+		}
+		if err := ch.Notify(r); err != nil {
+			d.log.Warn("发送通知失败", zap.String("channel", ch.Name()), zap.Error(err))
+			continue
+		}
+		d.log.Info("已发送通知", zap.String("channel", ch.Name()))
+	}
+}
+
+func shouldNotify(policy string, r *signin.Result, changed bool) bool {
+	switch policy {
+	case PolicyAlways:
+		return true
+	case PolicyChange:
+		return changed
+	default:
+		return !r.Success()
+	}
+}
+
+func validatePolicy(policy string) error {
+	switch policy {
+	case PolicyAlways, PolicyFailure, PolicyChange:
+		return nil
+	default:
+		return fmt.Errorf("未知的通知策略 %q", policy)
+	}
+}
+
+// Title 返回签到结果的简短标题
+func Title(r *signin.Result) string {
+	if r.Success() {
+		return fmt.Sprintf("[%s] 签到成功", r.DisplayName())
+	}
+	return fmt.Sprintf("[%s] 签到失败", r.DisplayName())
+}
+
+// Text 返回签到结果的纯文本摘要
+func Text(r *signin.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "账号: %s\n", r.DisplayName())
+	if r.Schedule != "" {
+		fmt.Fprintf(&b, "来源: %s\n", r.Schedule)
+	}
+	if r.TaskType != "" {
+		fmt.Fprintf(&b, "任务: %s (ID: %d, 批次: %d)\n", r.TaskType, r.SigninID, r.BatchNo)
+	} else if r.Success() {
+		b.WriteString("任务: 没有需要签到的任务\n")
+	}
+	fmt.Fprintf(&b, "位置: %.6f, %.6f\n", r.Lng, r.Lat)
+	fmt.Fprintf(&b, "时间: %s\n", r.StartedAt.Format("2006-01-02 15:04:05"))
+	if r.Err != nil {
+		fmt.Fprintf(&b, "错误: %v\n", r.Err)
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+)
+
+// recorder 记录收到的签到结果
+type recorder struct {
+	name string
+	got  []bool
+}
+
+func (r *recorder) Name() string { return r.name }
+
+func (r *recorder) Notify(res *signin.Result) error {
+	r.got = append(r.got, res.Success())
+	return nil
+}
+
+func TestDispatchPolicies(t *testing.T) {
+	ok := &signin.Result{Account: "20230001"}
+	failed := &signin.Result{Account: "20230001", Err: errors.New("签到失败")}
+	runs := []*signin.Result{ok, ok, failed, failed, ok}
+
+	tests := []struct {
+		policy string
+		want   []bool
+	}{
+		{PolicyAlways, []bool{true, true, false, false, true}},
+		{PolicyFailure, []bool{false, false}},
+		// 首次运行没有记录，视为状态变化
+		{PolicyChange, []bool{true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := New(config.NotifyConfig{On: tt.policy}, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := &recorder{name: "recorder"}
+			if err := d.Add(rec, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, res := range runs {
+				d.Dispatch(res)
+			}
+			if !slices.Equal(rec.got, tt.want) {
+				t.Errorf("notified %v, want %v", rec.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchChannelOverride(t *testing.T) {
+	d, err := New(config.NotifyConfig{On: PolicyFailure}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	global := &recorder{name: "global"}
+	always := &recorder{name: "always"}
+	if err := d.Add(global, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(always, PolicyAlways); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Dispatch(&signin.Result{Account: "20230001"})
+	if len(global.got) != 0 || len(always.got) != 1 {
+		t.Errorf("global notified %d times, always notified %d times, want 0 and 1", len(global.got), len(always.got))
+	}
+}
+
+func TestUnknownPolicy(t *testing.T) {
+	if _, err := New(config.NotifyConfig{On: "sometimes"}, nil); err == nil {
+		t.Error("New accepted an unknown policy")
+	}
+	d, _ := New(config.NotifyConfig{}, nil)
+	if err := d.Add(&recorder{name: "recorder"}, "sometimes"); err == nil {
+		t.Error("Add accepted an unknown policy")
+	}
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"fmt"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/telegram"
+)
+
+// Telegram 通过 Telegram 机器人向白名单中的聊天推送签到结果
+type Telegram struct {
+	cfg    config.TelegramConfig
+	client *telegram.Client
+}
+
+// NewTelegram 创建一个新的 Telegram 通知渠道
+func NewTelegram(cfg config.TelegramConfig) (*Telegram, error) {
+	if cfg.Token == "" || len(cfg.ChatIDs) == 0 {
+		return nil, fmt.Errorf("telegram: token 和 chat_ids 不能为空")
+	}
+	return &Telegram{cfg: cfg, client: telegram.NewClient(cfg.APIBase, cfg.Token)}, nil
+}
+
+// Name 返回渠道名称
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Notify 向所有白名单聊天发送签到结果
+func (t *Telegram) Notify(r *signin.Result) error {
+	text := Title(r) + "\n\n" + Text(r)
+	for _, id := range t.cfg.ChatIDs {
+		if err := t.client.SendMessage(id, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
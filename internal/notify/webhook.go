@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+)
+
+// defaultWebhookBody 是未配置 body 时使用的 JSON 模板
+const defaultWebhookBody = `{"account":{{json .Account}},"name":{{json .Name}},"schedule":{{json .Schedule}},"success":{{.Success}},` +
+	`"task_type":{{json .TaskType}},"signin_id":{{.SigninID}},"batch_no":{{.BatchNo}},"pending":{{.Pending}},` +
+	`"lng":{{.Lng}},"lat":{{.Lat}},"started_at":{{json .StartedAt}},"finished_at":{{json .FinishedAt}},` +
+	`"error":{{json (errString .Err)}}}`
+
+// Webhook 将签到结果以模板渲染后发送到通用 HTTP 接口
+type Webhook struct {
+	cfg    config.WebhookConfig
+	client *resty.Client
+	body   *template.Template
+}
+
+// NewWebhook 创建一个新的 Webhook 通知渠道
+func NewWebhook(cfg config.WebhookConfig) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook: 未配置 url")
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = "X-Signature"
+	}
+
+	text := cfg.Body
+	if text == "" {
+		text = defaultWebhookBody
+	}
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: 解析 body 模板失败: %w", err)
+	}
+
+	client := resty.New().
+		SetTimeout(15 * time.Second).
+		SetRetryCount(cfg.Retries).
+		SetRetryWaitTime(cfg.RetryInterval).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			// 仅在网络错误或服务端 5xx 时重试
+			return err != nil || resp.StatusCode() >= http.StatusInternalServerError
+		})
+
+	return &Webhook{cfg: cfg, client: client, body: tmpl}, nil
+}
+
+// Name 返回渠道名称
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Notify 渲染模板并发送签到结果
+func (w *Webhook) Notify(r *signin.Result) error {
+	var buf bytes.Buffer
+	if err := w.body.Execute(&buf, r); err != nil {
+		return fmt.Errorf("渲染 webhook 模板失败: %w", err)
+	}
+	payload := buf.Bytes()
+
+	req := w.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeaders(w.cfg.Headers).
+		SetBody(payload)
+	if w.cfg.Secret != "" {
+		req.SetHeader(w.cfg.SignatureHeader, "sha256="+Sign(w.cfg.Secret, payload))
+	}
+
+	resp, err := req.Execute(strings.ToUpper(w.cfg.Method), w.cfg.URL)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("webhook 请求失败: %s", resp.Status())
+	}
+	return nil
+}
+
+// Sign 返回 payload 的 HMAC-SHA256 十六进制签名
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// templateFuncs 是渲染通知模板时可用的函数
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"errString": func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	},
+}
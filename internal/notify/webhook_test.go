@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/signin"
+)
+
+// webhookResult 是发送到 webhook 的签到结果
+var webhookResult = &signin.Result{
+	Account:   "20230001",
+	Name:      "张三",
+	Schedule:  "morning",
+	TaskType:  "实习",
+	SigninID:  7,
+	BatchNo:   20250801,
+	Lng:       109.4,
+	Lat:       24.3,
+	StartedAt: time.Date(2025, 7, 14, 8, 3, 0, 0, time.UTC),
+	Err:       errors.New("签到已结束"),
+}
+
+// webhookServer 启动记录请求的假服务器，依次以 statuses 中的状态码响应，用完后返回 200
+func webhookServer(t *testing.T, statuses ...int) (url string, reqs <-chan *http.Request, bodies <-chan []byte, hits *int32) {
+	t.Helper()
+	reqCh := make(chan *http.Request, 10)
+	bodyCh := make(chan []byte, 10)
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqCh <- r
+		bodyCh <- body
+		if i := int(atomic.AddInt32(&n, 1)) - 1; i < len(statuses) {
+			w.WriteHeader(statuses[i])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, reqCh, bodyCh, &n
+}
+
+func TestWebhookDefaultBody(t *testing.T) {
+	url, reqs, bodies, _ := webhookServer(t)
+	w, err := NewWebhook(config.WebhookConfig{URL: url + "/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(webhookResult); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req, body := <-reqs, captured{body: <-bodies}
+	if req.Method != http.MethodPost || req.URL.Path != "/hook" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("%s %s, Content-Type %q", req.Method, req.URL.Path, req.Header.Get("Content-Type"))
+	}
+	if req.Header.Get("X-Signature") != "" {
+		t.Error("request signed without a secret")
+	}
+	m := body.jsonBody(t)
+	if m["account"] != "20230001" || m["schedule"] != "morning" || m["success"] != false ||
+		m["signin_id"] != float64(7) || m["lat"] != 24.3 || m["error"] != "签到已结束" {
+		t.Errorf("unexpected body %s", body.body)
+	}
+}
+
+func TestWebhookTemplateAndSignature(t *testing.T) {
+	url, reqs, bodies, _ := webhookServer(t)
+	w, err := NewWebhook(config.WebhookConfig{
+		URL:             url,
+		Method:          "put",
+		Headers:         map[string]string{"X-Source": "zhxg-signin"},
+		Body:            `{"text":"{{.DisplayName}} {{errString .Err}}"}`,
+		Secret:          "s3cret",
+		SignatureHeader: "X-Hub-Signature-256",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(webhookResult); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	req, body := <-reqs, <-bodies
+	if req.Method != http.MethodPut || req.Header.Get("X-Source") != "zhxg-signin" {
+		t.Errorf("method %s, X-Source %q", req.Method, req.Header.Get("X-Source"))
+	}
+	if want := `{"text":"20230001 (张三) 签到已结束"}`; string(body) != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+	if got, want := req.Header.Get("X-Hub-Signature-256"), "sha256="+Sign("s3cret", body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantHits int32
+		wantErr  bool
+	}{
+		{name: "5xx 后重试成功", statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable}, wantHits: 3},
+		{name: "重试次数用完", statuses: []int{500, 500, 500, 500}, wantHits: 3, wantErr: true},
+		{name: "4xx 不重试", statuses: []int{http.StatusBadRequest}, wantHits: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, _, _, hits := webhookServer(t, tt.statuses...)
+			w, err := NewWebhook(config.WebhookConfig{URL: url, Retries: 2, RetryInterval: time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Notify(webhookResult)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(hits); got != tt.wantHits {
+				t.Errorf("server received %d requests, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWebhookInvalid(t *testing.T) {
+	if _, err := NewWebhook(config.WebhookConfig{}); err == nil {
+		t.Error("NewWebhook accepted an empty url")
+	}
+	if _, err := NewWebhook(config.WebhookConfig{URL: "http://127.0.0.1", Body: "{{.Account"}); err == nil {
+		t.Error("NewWebhook accepted an invalid template")
+	}
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/cassette"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/metrics"
+	"zhxg-signin/internal/notify"
+	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/wisestu"
+)
+
+// Runner 串行执行签到，并在每次运行后处理通知等后续动作
+type Runner struct {
+	mu       sync.Mutex
+	service  *signin.Service
+	notifier *notify.Dispatcher
+	history  history.Store
+	log      *zap.Logger
+}
+
+// New 创建一个新的 Runner，每次运行的结果会写入 hist。
+// opts 会传给 signin.NewService，用于替换签到服务的默认依赖
+func New(cfg config.Config, store *state.Store, hist history.Store, opts ...signin.Option) (*Runner, error) {
+	notifier, err := notify.New(cfg.Notify, store)
+	if err != nil {
+		return nil, err
+	}
+
+	service := signin.NewService(cfg, store, opts...)
+	if cfg.Cassette.Mode != "" {
+		api, err := cassette.Open(filepath.Join(cfg.Cassette.Dir, "wisestu.json"), cfg.Cassette.Mode)
+		if err != nil {
+			return nil, err
+		}
+		llm, err := cassette.Open(filepath.Join(cfg.Cassette.Dir, "llm.json"), cfg.Cassette.Mode)
+		if err != nil {
+			return nil, err
+		}
+		service.WrapTransports(api.Wrap, llm.Wrap)
+		logger.GetLogger().Info("已启用 HTTP 请求录制/回放", zap.String("mode", cfg.Cassette.Mode), zap.String("dir", cfg.Cassette.Dir))
+	}
+
+	return &Runner{
+		service:  service,
+		notifier: notifier,
+		history:  hist,
+		log:      logger.GetLogger(),
+	}, nil
+}
+
+// Run 执行一次签到并发送通知，source 记录触发来源，如定时计划名称
+func (r *Runner) Run(source string) *signin.Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res, err := r.service.Run()
+	return r.complete(source, res, err)
+}
+
+// RunTasks 从 PendingTasks 返回的 tasks 中选择任务签到并发送通知，不再重新获取未签到列表
+func (r *Runner) RunTasks(source string, tasks []signin.SigninTask) *signin.Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res, err := r.service.RunTasks(tasks)
+	return r.complete(source, res, err)
+}
+
+// complete 记录一次运行的日志、指标和运行记录并发送通知
+func (r *Runner) complete(source string, res *signin.Result, err error) *signin.Result {
+	res.Schedule = source
+	if err != nil {
+		r.log.Error("签到任务失败", zap.String("source", source), zap.Error(err))
+	} else {
+		r.log.Info("签到任务成功", zap.String("source", source))
+	}
+
+	metrics.ObserveRun(res.Account, res.Success(), res.Submitted(), res.ErrorClass, res.StartedAt)
+	r.record(res)
+	r.notifier.Dispatch(res)
+	return res
+}
+
+// PendingTasks 返回当前未签到的任务列表
+func (r *Runner) PendingTasks() ([]signin.SigninTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.service.PendingTasks()
+}
+
+// Location 返回签到使用的位置
+func (r *Runner) Location() config.LocationConfig {
+	return r.service.Location()
+}
+
+// Profile 返回当前账号的学生信息
+func (r *Runner) Profile() (*wisestu.Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.service.Profile()
+}
+
+// ValidateFlows 对照签到方式注册表检查配置的签到方式
+func (r *Runner) ValidateFlows() error {
+	return r.service.ValidateFlows()
+}
+
+// ProbeSession 检查保存的会话是否仍然有效，不会重新登录
+func (r *Runner) ProbeSession() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.service.ProbeSession()
+}
+
+// record 保存运行记录
+func (r *Runner) record(res *signin.Result) {
+	if err := r.history.Add(history.FromResult(res)); err != nil {
+		r.log.Warn("保存运行记录失败", zap.Error(err))
+	}
+}
@@ -1,48 +1,227 @@
 package scheduler
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
+	"zhxg-signin/internal/calendar"
 	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/health"
+	"zhxg-signin/internal/history"
 	"zhxg-signin/internal/logger"
-	"zhxg-signin/internal/signin"
+	"zhxg-signin/internal/runner"
+	"zhxg-signin/internal/state"
 )
 
-// StartScheduler 启动定时签到任务
-func StartScheduler(cfg config.Config) {
-	log := logger.GetLogger()
-	if !cfg.Scheduler.Enabled {
-		log.Info("定时任务未启用")
-		return
-	}
+const (
+	heartbeatSpec = "@every 30s"
+	heartbeatTTL  = 2 * time.Minute
+)
+
+// ErrUnknownSchedule 表示指定的定时计划不存在
+var ErrUnknownSchedule = errors.New("未知的定时计划")
+
+// Scheduler 按 cron 表达式执行定时签到，所有计划共用同一个 Runner
+type Scheduler struct {
+	cfg       config.Config
+	store     *state.Store
+	runner    *runner.Runner
+	cron      *cron.Cron
+	loc       *time.Location
+	cal       *calendar.Calendar
+	schedules []config.ScheduleConfig
+	entries   map[string]cron.EntryID
+	monitor   *health.Monitor
+	log       *zap.Logger
+}
 
+// ScheduleStatus 是单个定时计划的当前状态
+type ScheduleStatus struct {
+	Name    string    `json:"name"`
+	Cron    string    `json:"cron"`
+	Window  string    `json:"window"`
+	Paused  bool      `json:"paused"`
+	Next    time.Time `json:"next"`
+	LastRun time.Time `json:"last_run"`
+}
+
+// New 创建定时调度器并注册所有定时计划，调用 Start 后开始执行。
+// 调度器的心跳上报给 mon，为 nil 时不上报
+func New(cfg config.Config, store *state.Store, hist history.Store, r *runner.Runner, mon *health.Monitor) (*Scheduler, error) {
 	loc, err := time.LoadLocation(cfg.Scheduler.Timezone)
 	if err != nil {
-		log.Error("加载时区失败", zap.Error(err))
-		return
+		return nil, fmt.Errorf("加载时区失败: %w", err)
 	}
 
-	c := cron.New(cron.WithLocation(loc))
-	_, err = c.AddFunc(cfg.Scheduler.Cron, func() {
-		log.Info("开始执行定时签到任务")
-		service := signin.NewService(cfg)
-		if err := service.Run(); err != nil {
-			log.Error("定时签到任务失败", zap.Error(err))
-		} else {
-			log.Info("定时签到任务成功")
-		}
-	})
+	schedules := cfg.Scheduler.ScheduleList()
+	if len(schedules) == 0 {
+		return nil, errors.New("未配置任何定时计划")
+	}
 
+	cal, err := calendar.Load(cfg.Calendar)
 	if err != nil {
-		log.Error("添加 cron 任务失败", zap.Error(err))
+		return nil, fmt.Errorf("加载节假日日历失败: %w", err)
+	}
+
+	s := &Scheduler{
+		cfg:       cfg,
+		store:     store,
+		runner:    r,
+		cron:      cron.New(cron.WithLocation(loc)),
+		loc:       loc,
+		cal:       cal,
+		schedules: schedules,
+		monitor:   mon,
+		entries:   make(map[string]cron.EntryID, len(schedules)),
+		log:       logger.GetLogger(),
+	}
+
+	jitter := NewJitter(cfg.Scheduler.Seed)
+	for _, sc := range schedules {
+		sc := sc
+		if err := sc.Validate(); err != nil {
+			return nil, err
+		}
+		id, err := s.cron.AddFunc(sc.Cron, func() {
+			if s.store.Paused(sc.Name) {
+				s.log.Info("定时计划已暂停，跳过本次签到", zap.String("schedule", sc.Name))
+				return
+			}
+			if skip, reason := s.cal.Check(time.Now().In(loc)); skip {
+				s.log.Info("跳过本次定时签到", zap.String("schedule", sc.Name), zap.String("reason", reason))
+				return
+			}
+
+			delay := jitter.Delay(sc.Window.Length)
+			s.log.Info("定时任务已触发",
+				zap.String("schedule", sc.Name),
+				zap.Duration("delay", delay),
+				zap.Time("planned", time.Now().In(loc).Add(delay)))
+			time.Sleep(delay)
+
+			s.runJob(sc.Name)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("添加定时计划 %s 失败: %w", sc.Name, err)
+		}
+		s.entries[sc.Name] = id
+		s.log.Info("已添加定时计划", zap.String("schedule", sc.Name), zap.String("cron", sc.Cron), zap.Stringer("window", sc.Window))
+	}
+
+	if err := addDigest(s.cron, cfg, hist, loc); err != nil {
+		return nil, fmt.Errorf("添加签到日报任务失败: %w", err)
+	}
+
+	// 定期上报心跳，供 /healthz 判断调度器是否仍在运行
+	if _, err := s.cron.AddFunc(heartbeatSpec, func() { s.monitor.Beat("scheduler", heartbeatTTL) }); err != nil {
+		return nil, fmt.Errorf("添加心跳任务失败: %w", err)
+	}
+	return s, nil
+}
+
+// Start 检查错过的执行并启动调度，该方法不会阻塞
+func (s *Scheduler) Start() {
+	if s.cfg.Scheduler.CatchUp.Enabled {
+		missed := findMissed(s.schedules, s.store, s.cal, time.Now().In(s.loc), s.cfg.Scheduler.CatchUp.Lookback)
+		if len(missed) > 0 {
+			// 错过的多次执行只补签一次，任务已不在未签到列表中时 Run 会直接返回
+			s.log.Info("检测到错过的定时签到，执行一次补签", zap.Strings("schedules", missed))
+			go s.runJob(missed...)
+		}
+	}
+
+	s.monitor.Beat("scheduler", heartbeatTTL)
+	s.log.Info("定时任务已启动", zap.Int("schedules", len(s.schedules)))
+	s.cron.Start()
+}
+
+// Schedules 返回所有定时计划的当前状态
+func (s *Scheduler) Schedules() []ScheduleStatus {
+	statuses := make([]ScheduleStatus, 0, len(s.schedules))
+	for _, sc := range s.schedules {
+		statuses = append(statuses, ScheduleStatus{
+			Name:    sc.Name,
+			Cron:    sc.Cron,
+			Window:  sc.Window.String(),
+			Paused:  s.store.Paused(sc.Name),
+			Next:    s.cron.Entry(s.entries[sc.Name]).Next,
+			LastRun: s.store.LastRun(sc.Name),
+		})
+	}
+	return statuses
+}
+
+// Pause 暂停定时计划，暂停期间到点不会签到，也不会补签
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume 恢复已暂停的定时计划
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	if _, ok := s.entries[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSchedule, name)
+	}
+	if err := s.store.SetPaused(name, paused); err != nil {
+		return err
+	}
+	s.log.Info("已更新定时计划状态", zap.String("schedule", name), zap.Bool("paused", paused))
+	return nil
+}
+
+// runJob 执行一次签到，成功后记录各计划的最近执行时间
+func (s *Scheduler) runJob(names ...string) {
+	start := time.Now().In(s.loc)
+	s.log.Info("开始执行定时签到任务", zap.Strings("schedules", names), zap.Time("start", start))
+	if res := s.runner.Run(strings.Join(names, ",")); !res.Success() {
 		return
 	}
+	for _, name := range names {
+		if err := s.store.SetLastRun(name, start); err != nil {
+			s.log.Warn("保存执行状态失败", zap.String("schedule", name), zap.Error(err))
+		}
+	}
+}
+
+// findMissed 返回在回溯窗口内错过执行的定时计划名称，从未成功执行过的计划不计入
+func findMissed(schedules []config.ScheduleConfig, store *state.Store, cal *calendar.Calendar, now time.Time, lookback time.Duration) []string {
+	log := logger.GetLogger()
 
-	log.Info("定时任务已启动", zap.String("cron", cfg.Scheduler.Cron))
-	c.Start()
+	var missed []string
+	for _, sc := range schedules {
+		if store.Paused(sc.Name) {
+			continue
+		}
+		sched, err := cron.ParseStandard(sc.Cron)
+		if err != nil {
+			continue // 无效的表达式会在 AddFunc 时报错
+		}
 
-	// 阻塞主 goroutine
-	select {}
-}
\ No newline at end of file
+		// 没有执行记录时无从判断是否错过，如首次启动，不补签
+		last := store.LastRun(sc.Name)
+		if last.IsZero() {
+			continue
+		}
+		since := now.Add(-lookback)
+		if last.After(since) {
+			since = last.In(now.Location())
+		}
+
+		for t := sched.Next(since); !t.IsZero() && t.Before(now); t = sched.Next(t) {
+			if skip, _ := cal.Check(t); skip {
+				continue
+			}
+			log.Info("发现错过的定时签到", zap.String("schedule", sc.Name), zap.Time("missed", t))
+			missed = append(missed, sc.Name)
+			break
+		}
+	}
+	return missed
+}
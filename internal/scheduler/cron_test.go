@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/state"
+)
+
+func TestFindMissed(t *testing.T) {
+	now := time.Date(2025, 7, 14, 10, 0, 0, 0, time.UTC) // 周一
+	schedules := []config.ScheduleConfig{{Name: "morning", Cron: "0 8 * * *"}}
+
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		want    int
+	}{
+		{"首次启动没有执行记录", time.Time{}, 0},
+		{"上次执行后错过一次", now.Add(-26 * time.Hour), 1},
+		{"今天已执行", now.Add(-time.Hour), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.lastRun.IsZero() {
+				if err := store.SetLastRun("morning", tt.lastRun); err != nil {
+					t.Fatal(err)
+				}
+			}
+			missed := findMissed(schedules, store, nil, now, 12*time.Hour)
+			if len(missed) != tt.want {
+				t.Errorf("findMissed = %v, want %d schedules", missed, tt.want)
+			}
+		})
+	}
+}
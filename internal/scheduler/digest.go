@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/notify"
+)
+
+// addDigest 在配置启用时向 c 添加每日汇总邮件任务
+func addDigest(c *cron.Cron, cfg config.Config, hist history.Store, loc *time.Location) error {
+	digest := cfg.Notify.Email.Digest
+	if !cfg.Notify.Email.Enabled || !digest.Enabled {
+		return nil
+	}
+
+	email, err := notify.NewEmail(cfg.Notify.Email)
+	if err != nil {
+		return err
+	}
+
+	at, err := config.ParseClock(digest.Time)
+	if err != nil {
+		return fmt.Errorf("解析日报发送时间失败: %w", err)
+	}
+	spec := fmt.Sprintf("%d %d * * *", int(at.Minutes())%60, int(at.Hours()))
+
+	log := logger.GetLogger()
+	_, err = c.AddFunc(spec, func() {
+		now := time.Now().In(loc)
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		runs, err := hist.List(history.Query{Since: day, Until: day.AddDate(0, 0, 1)})
+		if err != nil {
+			log.Error("查询当天运行记录失败", zap.Error(err))
+			return
+		}
+		if err := email.SendDigest(day, []string{cfg.User.Username}, runs); err != nil {
+			log.Error("发送签到日报失败", zap.Error(err))
+			return
+		}
+		log.Info("已发送签到日报", zap.Int("runs", len(runs)))
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info("已启用签到日报", zap.String("time", digest.Time))
+	return nil
+}
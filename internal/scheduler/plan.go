@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"zhxg-signin/internal/calendar"
+	"zhxg-signin/internal/config"
+)
+
+// PlannedRun 是一次计划中的定时签到
+type PlannedRun struct {
+	Fire       time.Time // cron 触发时间
+	Latest     time.Time // 执行窗口的结束时间，等于 Fire 表示没有窗口
+	Skipped    bool
+	SkipReason string
+}
+
+// SchedulePlan 是单个定时计划接下来的执行安排
+type SchedulePlan struct {
+	Account  string
+	Schedule config.ScheduleConfig
+	Runs     []PlannedRun
+	Err      error // cron 表达式无效时不为 nil
+}
+
+// Plan 计算每个定时计划接下来的 n 次执行，被日历跳过的执行会被标记但不计入 n
+func Plan(cfg config.Config, now time.Time, n int) ([]SchedulePlan, error) {
+	loc, err := time.LoadLocation(cfg.Scheduler.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("加载时区失败: %w", err)
+	}
+
+	cal, err := calendar.Load(cfg.Calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []SchedulePlan
+	for _, sc := range cfg.Scheduler.ScheduleList() {
+		plan := SchedulePlan{Account: cfg.User.Username, Schedule: sc}
+
+		sched, err := cron.ParseStandard(sc.Cron)
+		if err != nil {
+			plan.Err = fmt.Errorf("无效的 cron 表达式 %q: %w", sc.Cron, err)
+			plans = append(plans, plan)
+			continue
+		}
+		if err := sc.Validate(); err != nil {
+			plan.Err = err
+			plans = append(plans, plan)
+			continue
+		}
+
+		// 限制迭代次数，避免日历跳过所有日期时陷入死循环
+		planned := 0
+		t := now.In(loc)
+		for i := 0; planned < n && i < n*50; i++ {
+			t = sched.Next(t)
+			if t.IsZero() {
+				break
+			}
+			run := PlannedRun{Fire: t, Latest: t.Add(sc.Window.Length)}
+			if skip, reason := cal.Check(t); skip {
+				run.Skipped, run.SkipReason = true, reason
+			} else {
+				planned++
+			}
+			plan.Runs = append(plan.Runs, run)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"zhxg-signin/internal/calendar"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/health"
+	"zhxg-signin/internal/history"
+	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/runner"
+	"zhxg-signin/internal/signin"
+)
+
+const (
+	defaultWatchInterval    = time.Minute
+	defaultWatchMaxInterval = 15 * time.Minute
+	// watchRunGrace 是心跳超时时间中为一次签到预留的时长
+	watchRunGrace = 10 * time.Minute
+)
+
+// StartWatcher 以轮询模式运行，发现待签到任务后立即签到，心跳上报给 mon
+func StartWatcher(cfg config.Config, hist history.Store, r *runner.Runner, mon *health.Monitor) {
+	log := logger.GetLogger()
+
+	loc, err := time.LoadLocation(cfg.Scheduler.Timezone)
+	if err != nil {
+		log.Error("加载时区失败", zap.Error(err))
+		return
+	}
+
+	hours, err := parseActiveHours(cfg.Watch.ActiveHours)
+	if err != nil {
+		log.Error("解析轮询时段失败", zap.Error(err))
+		return
+	}
+
+	cal, err := calendar.Load(cfg.Calendar)
+	if err != nil {
+		log.Error("加载节假日日历失败", zap.Error(err))
+		return
+	}
+
+	minInterval := cfg.Watch.Interval
+	if minInterval <= 0 {
+		minInterval = defaultWatchInterval
+	}
+	maxInterval := cfg.Watch.MaxInterval
+	if maxInterval < minInterval {
+		maxInterval = max(minInterval, defaultWatchMaxInterval)
+	}
+
+	digest := cron.New(cron.WithLocation(loc))
+	if err := addDigest(digest, cfg, hist, loc); err != nil {
+		log.Error("添加签到日报任务失败", zap.Error(err))
+		return
+	}
+	digest.Start()
+
+	// 复用同一个 Runner，使轮询期间沿用已登录的会话
+	interval := minInterval
+
+	log.Info("轮询模式已启动",
+		zap.Duration("interval", minInterval),
+		zap.Duration("max_interval", maxInterval),
+		zap.String("active_hours", cfg.Watch.ActiveHours),
+		zap.Strings("task_types", cfg.Watch.TaskTypes))
+
+	// sleep 在休眠前上报心跳，承诺在休眠结束并完成一次签到前再次上报
+	sleep := func(d time.Duration) {
+		mon.Beat("watcher", d+watchRunGrace)
+		time.Sleep(d)
+	}
+
+	for {
+		mon.Beat("watcher", watchRunGrace)
+		now := time.Now().In(loc)
+		if wait := hours.until(now); wait > 0 {
+			log.Info("不在轮询时段内，等待下一时段", zap.Duration("wait", wait))
+			sleep(wait)
+			interval = minInterval
+			continue
+		}
+
+		if skip, reason := cal.Check(now); skip {
+			log.Info("今日跳过轮询", zap.String("reason", reason))
+			sleep(maxInterval)
+			continue
+		}
+
+		tasks, err := r.PendingTasks()
+		matched := matchTasks(tasks, cfg.Watch.TaskTypes)
+		switch {
+		case err != nil:
+			log.Warn("获取待签到任务失败", zap.Error(err))
+			interval = backoff(interval, maxInterval)
+		case len(matched) == 0:
+			interval = backoff(interval, maxInterval)
+			log.Debug("没有匹配的待签到任务", zap.Int("pending", len(tasks)), zap.Duration("next", interval))
+		default:
+			// 只在匹配的任务中选择，并沿用刚获取的列表，不再重复登录和获取列表
+			log.Info("发现待签到任务，立即签到", zap.Int("pending", len(tasks)), zap.Int("matched", len(matched)))
+			res := r.RunTasks("watch", matched)
+			switch {
+			case res.Success():
+				interval = minInterval
+			case res.ErrorClass == signin.ErrorClassNotOpen && !res.OpensAt.IsZero():
+				// 签到尚未开始，等到开始时间再签到，不必按退避间隔反复尝试
+				interval = max(time.Until(res.OpensAt), minInterval)
+				log.Info("签到尚未开始，等待开始时间", zap.Time("opens_at", res.OpensAt), zap.Duration("wait", interval))
+			default:
+				interval = backoff(interval, maxInterval)
+			}
+		}
+
+		sleep(interval)
+	}
+}
+
+// matchTasks 按任务类型过滤待签到任务，types 为空时返回全部任务
+func matchTasks(tasks []signin.SigninTask, types []string) []signin.SigninTask {
+	if len(types) == 0 {
+		return tasks
+	}
+	var matched []signin.SigninTask
+	for _, task := range tasks {
+		for _, t := range types {
+			if task.SigninTypeName == t {
+				matched = append(matched, task)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func backoff(interval, maxInterval time.Duration) time.Duration {
+	return min(interval*2, maxInterval)
+}
+
+// activeHours 表示每天允许轮询的时段，零值表示全天
+type activeHours struct {
+	start, end time.Duration // 距当天零点的偏移
+}
+
+func parseActiveHours(s string) (activeHours, error) {
+	if s == "" {
+		return activeHours{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return activeHours{}, fmt.Errorf("无效的时段 %q，应为 HH:MM-HH:MM", s)
+	}
+	start, err := config.ParseClock(from)
+	if err != nil {
+		return activeHours{}, err
+	}
+	end, err := config.ParseClock(to)
+	if err != nil {
+		return activeHours{}, err
+	}
+	return activeHours{start: start, end: end}, nil
+}
+
+// until 返回距离下一个轮询时段开始的时长，当前处于时段内时返回 0
+func (h activeHours) until(now time.Time) time.Duration {
+	if h.start == h.end {
+		return 0
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+
+	if h.start < h.end {
+		if offset >= h.start && offset < h.end {
+			return 0
+		}
+		next := midnight.Add(h.start)
+		if offset >= h.end {
+			next = midnight.AddDate(0, 0, 1).Add(h.start)
+		}
+		return next.Sub(now)
+	}
+
+	// 跨越零点的时段，如 "22:00-06:00"
+	if offset >= h.start || offset < h.end {
+		return 0
+	}
+	return midnight.Add(h.start).Sub(now)
+}
@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Jitter 用于在执行窗口内挑选随机延迟
+type Jitter struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewJitter 创建一个新的 Jitter，seed 为 0 时使用当前时间作为种子
+func NewJitter(seed int64) *Jitter {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Jitter{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// Delay 返回 [0, window) 内的随机延迟，window 不大于 0 时返回 0
+func (j *Jitter) Delay(window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return time.Duration(j.rnd.Int63n(int64(window)))
+}
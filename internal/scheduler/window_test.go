@@ -0,0 +1,29 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterSeed(t *testing.T) {
+	const window = 25 * time.Minute
+	a, b := NewJitter(42), NewJitter(42)
+	for i := 0; i < 10; i++ {
+		da, db := a.Delay(window), b.Delay(window)
+		if da != db {
+			t.Fatalf("delay %d: %v != %v with the same seed", i, da, db)
+		}
+		if da < 0 || da >= window {
+			t.Errorf("delay %v out of [0, %v)", da, window)
+		}
+	}
+}
+
+func TestJitterNoWindow(t *testing.T) {
+	j := NewJitter(1)
+	for _, window := range []time.Duration{0, -time.Minute} {
+		if d := j.Delay(window); d != 0 {
+			t.Errorf("Delay(%v) = %v, want 0", window, d)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/logger"
+)
+
+// Server 是守护进程内置的 HTTP 服务，各功能模块通过 Handle 注册路由
+type Server struct {
+	cfg config.ServerConfig
+	mux *http.ServeMux
+	log *zap.Logger
+}
+
+// New 创建一个新的 HTTP 服务
+func New(cfg config.ServerConfig) *Server {
+	return &Server{
+		cfg: cfg,
+		mux: http.NewServeMux(),
+		log: logger.GetLogger(),
+	}
+}
+
+// Handle 注册一个路由，pattern 支持 "GET /path" 这样的写法
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP 按注册的路由处理请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Start 开始监听并处理请求，该方法会一直阻塞，监听失败或服务退出时返回错误
+func (s *Server) Start() error {
+	srv := &http.Server{
+		Addr:              s.cfg.Listen,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", s.cfg.Listen, err)
+	}
+	s.log.Info("HTTP 服务已启动", zap.String("listen", s.cfg.Listen))
+	return srv.Serve(ln)
+}
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"zhxg-signin/internal/config"
+)
+
+// 监听失败时 Start 应返回错误，而不是只记录日志
+func TestStartListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if err := New(config.ServerConfig{Listen: ln.Addr().String()}).Start(); err == nil {
+		t.Error("Start succeeded on an address in use")
+	}
+}
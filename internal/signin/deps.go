@@ -0,0 +1,98 @@
+package signin
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/wisestu"
+)
+
+// APIClient 是签到流程用到的智慧学工接口，默认实现为 wisestu.Client。
+// 接口返回非 0 响应码时应返回 *wisestu.APIError
+type APIClient interface {
+	// SetAuthToken 设置之后的请求携带的 token，为空时不携带
+	SetAuthToken(token string)
+	QueryVerificationQuestion() (*wisestu.Verification, error)
+	LoginStudent(loginName, password string, v *wisestu.Verification, answer string) (string, error)
+	QueryMyStuInfo() (*wisestu.Student, error)
+	GetUnSigninList(pageNum, pageSize int) ([]wisestu.SigninTask, error)
+	GetSigninDetails(id, batchNo int) (*wisestu.SigninDetails, error)
+	CheckOutsideFlag(id int, lng, lat float64) (outside bool, err error)
+	UpdateLocationSignin(id, batchNo int, loc wisestu.SigninLocation, outsideFlag string) error
+	UploadSigninImage(id, batchNo int, fileName string, data []byte) (string, error)
+	UpdatePhotoSignin(id, batchNo int, photoURL string, loc wisestu.SigninLocation) error
+	GetSigninSuccess(id, batchNo int) error
+}
+
+// Solver 是验证码识别器，默认实现为 captcha.LLMClient
+type Solver interface {
+	// Name 返回识别器名称，用于运行记录和指标
+	Name() string
+	// SolveCaptcha 识别 base64 编码的验证码图片并返回算式结果
+	SolveCaptcha(imageBase64 string) (int, error)
+}
+
+// Clock 提供当前时间和重试等待，测试中可替换为不真正等待的实现
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// SessionStore 保存登录会话和签到位置覆盖，默认实现为 state.Store
+type SessionStore interface {
+	Token() string
+	SetToken(token string) error
+	Location(account string) (state.Location, bool)
+}
+
+// Logger 是服务使用的日志接口，*zap.Logger 满足该接口
+type Logger interface {
+	Debug(msg string, fields ...zap.Field)
+	Info(msg string, fields ...zap.Field)
+	Warn(msg string, fields ...zap.Field)
+	Error(msg string, fields ...zap.Field)
+}
+
+// SessionRecorder 接收每次会话检查的结果，err 为 nil 表示会话有效，*health.Monitor 满足该接口
+type SessionRecorder interface {
+	RecordSession(account string, err error)
+}
+
+// Option 用于在创建 Service 时替换默认的依赖
+type Option func(*Service)
+
+// WithAPIClient 使用 api 访问智慧学工接口
+func WithAPIClient(api APIClient) Option {
+	return func(s *Service) { s.api = api }
+}
+
+// WithSolver 使用 solver 识别验证码
+func WithSolver(solver Solver) Option {
+	return func(s *Service) { s.solver = solver }
+}
+
+// WithClock 使用 clock 获取时间和等待重试
+func WithClock(clock Clock) Option {
+	return func(s *Service) { s.clock = clock }
+}
+
+// WithSessionStore 使用 store 保存登录会话，替换 NewService 传入的状态文件
+func WithSessionStore(store SessionStore) Option {
+	return func(s *Service) { s.store = store }
+}
+
+// WithLogger 使用 log 记录日志，替换全局日志
+func WithLogger(log Logger) Option {
+	return func(s *Service) { s.log = log }
+}
+
+// WithSessionRecorder 将会话检查的结果上报给 rec，用于就绪检查
+func WithSessionRecorder(rec SessionRecorder) Option {
+	return func(s *Service) { s.sessions = rec }
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
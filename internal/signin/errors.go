@@ -0,0 +1,73 @@
+package signin
+
+import (
+	"errors"
+	"net"
+)
+
+// ErrWrongPassword 表示登录时服务器返回密码错误 (code: 1002)
+var ErrWrongPassword = errors.New("密码错误")
+
+// 提交签到前根据签到详情发现的问题，重试不会成功
+var (
+	ErrNotOpen      = errors.New("签到尚未开始")
+	ErrClosed       = errors.New("签到已结束")
+	ErrOutsideFence = errors.New("签到位置不在签到范围内")
+	ErrNoPhoto      = errors.New("没有可上传的签到照片")
+)
+
+// ErrIdentityMismatch 表示登录账号的真实姓名与配置的 user.real_name 不一致
+var ErrIdentityMismatch = errors.New("学生信息与配置的姓名不一致")
+
+// 签到流程到达的阶段
+const (
+	StageLogin   = "login"   // 检查会话并登录
+	StageProfile = "profile" // 获取并核对学生信息
+	StageList    = "list"    // 获取未签到列表
+	StageDetails = "details" // 进入签到
+	StageCheck   = "check"   // 核对签到时间和范围
+	StageUpload  = "upload"  // 上传签到照片
+	StageSubmit  = "submit"  // 提交位置签到或拍照签到
+	StageConfirm = "confirm" // 获取签到情况
+	StageDone    = "done"
+)
+
+// 错误分类，用于历史记录和通知中区分失败原因
+const (
+	ErrorClassWrongPassword = "wrong_password"
+	ErrorClassIdentity      = "identity_mismatch"
+	ErrorClassNotOpen       = "not_open"
+	ErrorClassClosed        = "closed"
+	ErrorClassOutsideFence  = "outside_fence"
+	ErrorClassNoPhoto       = "no_photo"
+	ErrorClassNetwork       = "network"
+	ErrorClassLogin         = "login_failed"
+	ErrorClassSignin        = "signin_failed"
+)
+
+// ClassifyError 根据错误和失败时所处的阶段返回错误分类，err 为 nil 时返回空字符串
+func ClassifyError(err error, stage string) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrWrongPassword):
+		return ErrorClassWrongPassword
+	case errors.Is(err, ErrIdentityMismatch):
+		return ErrorClassIdentity
+	case errors.Is(err, ErrNotOpen):
+		return ErrorClassNotOpen
+	case errors.Is(err, ErrClosed):
+		return ErrorClassClosed
+	case errors.Is(err, ErrOutsideFence):
+		return ErrorClassOutsideFence
+	case errors.Is(err, ErrNoPhoto):
+		return ErrorClassNoPhoto
+	case errors.As(err, &netErr):
+		return ErrorClassNetwork
+	case stage == StageLogin:
+		return ErrorClassLogin
+	default:
+		return ErrorClassSignin
+	}
+}
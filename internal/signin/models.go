@@ -1,90 +1,53 @@
 package signin
 
-// BaseResponse 是许多 API 响应的基础结构
-type BaseResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
-// VerificationResponse 验证码请求的响应
-type VerificationResponse struct {
-	VerificationID    string `json:"verification_id"`
-	VerificationImage string `json:"verification_image"`
-}
-
-// LoginRequest 登录请求的结构
-type LoginRequest struct {
-	Action             string `json:"action"`
-	VerificationID     string `json:"verification_id"`
-	VerificationImage  string `json:"verification_image"`
-	VerificationAnswer string `json:"verification_answer"`
-	LoginName          string `json:"login_name"`
-	Password           string `json:"password"`
-	ClientType         string `json:"client_type"`
-	ClientVer          string `json:"client_ver"`
-	ClientExtra        string `json:"client_extra"`
-}
-
-// UnSigninListResponse 未签到列表的响应
-type UnSigninListResponse struct {
-	Result struct {
-		List []struct {
-			ID             int    `json:"id"`
-			SigninTypeName string `json:"signin_type_name"`
-			BatchNo        int    `json:"batch_no"`
-		} `json:"list"`
-	} `json:"result"`
-}
-
-// CheckOutsideFlagRequest 点击签到请求的结构
-type CheckOutsideFlagRequest struct {
-	Action string  `json:"action"`
-	ID     int     `json:"id"`
-	Lng    float64 `json:"lng"`
-	Lat    float64 `json:"lat"`
-}
-
-// GetSigninDetailsRequest 进入签到请求的结构
-type GetSigninDetailsRequest struct {
-	Action string `json:"action"`
-	ID     int    `json:"id"`
-	BatchNo int   `json:"batch_no"`
-}
-
-// UpdateLocationSigninRequest 更新位置签到请求的结构
-type UpdateLocationSigninRequest struct {
-	Action         string         `json:"action"`
-	ID             int            `json:"id"`
-	BatchNo        int            `json:"batch_no"`
-	SigninLocation string         `json:"signin_location"` // 这是一个JSON字符串
-	OutsideFlag    string         `json:"outside_flag"`
-}
-
-// SigninLocation 用于 UpdateLocationSigninRequest 中的 signin_location 字段
-type SigninLocation struct {
-	Point           SigninLocationPoint           `json:"point"`
-	Address         string                        `json:"address"`
-	AddressComponents SigninLocationAddressComponents `json:"addressComponents"`
-}
-
-// SigninLocationPoint 用于 SigninLocation 中的 point 字段
-type SigninLocationPoint struct {
-	Lng float64 `json:"lng"`
-	Lat float64 `json:"lat"`
-}
-
-// SigninLocationAddressComponents 用于 SigninLocation 中的 addressComponents 字段
-type SigninLocationAddressComponents struct {
-	StreetNumber string `json:"streetNumber"`
-	Street       string `json:"street"`
-	District     string `json:"district"`
-	City         string `json:"city"`
-	Province     string `json:"province"`
-}
-
-// GetSigninSuccessRequest 签到情况请求的结构
-type GetSigninSuccessRequest struct {
-	Action  string `json:"action"`
-	ID      int    `json:"id"`
-	BatchNo int    `json:"batch_no"`
-}
\ No newline at end of file
+import (
+	"time"
+
+	"zhxg-signin/internal/wisestu"
+)
+
+// Result 记录一次签到流程的结果
+type Result struct {
+	Account         string
+	Name            string // 学生信息中的真实姓名，获取失败时为空
+	Schedule        string // 触发本次签到的来源，如定时计划名称
+	Stage           string // 流程到达的阶段，失败时为出错的阶段
+	TaskType        string
+	Strategy        string // 提交签到使用的签到方式，如 location、photo
+	SigninID        int
+	BatchNo         int
+	Pending         int       // 未签到任务数量，为 0 时不会提交签到
+	OpensAt         time.Time // 签到尚未开始时为开始时间
+	TaskIDs         []int     // 未签到列表中所有任务的 ID
+	BatchNos        []int     // 与 TaskIDs 一一对应的批次号
+	CaptchaAttempts int
+	Solver          string
+	Lng             float64
+	Lat             float64
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	Err             error
+	ErrorClass      string
+}
+
+// DisplayName 返回带有真实姓名的账号，如 "20230001 (张三)"
+func (r *Result) DisplayName() string {
+	if r.Name == "" {
+		return r.Account
+	}
+	return r.Account + " (" + r.Name + ")"
+}
+
+// Success 返回本次签到是否成功
+func (r *Result) Success() bool {
+	return r.Err == nil
+}
+
+// Submitted 返回本次运行是否实际完成了一次签到。
+// 没有待签到任务或任务均为 skip 时运行也算成功，但没有提交签到
+func (r *Result) Submitted() bool {
+	return r.Success() && r.Stage == StageDone && r.SigninID != 0 && r.Strategy != ""
+}
+
+// SigninTask 未签到列表中的单个签到任务
+type SigninTask = wisestu.SigninTask
@@ -0,0 +1,83 @@
+package signin
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"zhxg-signin/internal/wisestutest"
+)
+
+// countActions 返回模拟服务收到的某个 action 的次数
+func countActions(mock *wisestutest.Server, action string) int {
+	n := 0
+	for _, a := range mock.Actions() {
+		if a == action {
+			n++
+		}
+	}
+	return n
+}
+
+// 针对模拟服务的预置场景运行完整的签到流程
+func TestRunScenarios(t *testing.T) {
+	task := wisestutest.DefaultTask
+	tests := []struct {
+		scenario  string
+		wantErr   error // 为 nil 且 wantClass 为空时要求签到成功
+		wantClass string
+		wantStage string
+		signed    bool
+		logins    int // 期望的 loginStudent 次数
+	}{
+		{scenario: wisestutest.ScenarioOK, wantStage: StageDone, signed: true, logins: 1},
+		// token 只能用于一次请求，登录后的四个签到请求都会遇到 Need Login，各自重新登录后重试
+		{scenario: wisestutest.ScenarioExpiredSession, wantStage: StageDone, signed: true, logins: 5},
+		// 前两次验证码错误，第三次登录成功
+		{scenario: wisestutest.ScenarioWrongCaptcha, wantStage: StageDone, signed: true, logins: 3},
+		{scenario: wisestutest.ScenarioMissingToken, wantClass: ErrorClassLogin, wantStage: StageLogin, logins: 5},
+		{scenario: wisestutest.ScenarioOutsideFence, wantErr: ErrOutsideFence, wantClass: ErrorClassOutsideFence, wantStage: StageCheck, logins: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			s, mock := newScenarioService(t, tt.scenario, testConfig())
+
+			res, err := s.Run()
+			switch {
+			case tt.wantErr == nil && tt.wantClass == "" && err != nil:
+				t.Fatalf("Run: %v", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Run error = %v, want %v", err, tt.wantErr)
+			case tt.wantClass != "" && err == nil:
+				t.Fatal("Run succeeded")
+			}
+			if res.ErrorClass != tt.wantClass || res.Stage != tt.wantStage {
+				t.Errorf("ErrorClass = %q, Stage = %q, want %q, %q", res.ErrorClass, res.Stage, tt.wantClass, tt.wantStage)
+			}
+			if _, ok := mock.Signed(task.ID); ok != tt.signed {
+				t.Errorf("signed = %v, want %v", ok, tt.signed)
+			}
+			if got := countActions(mock, "loginStudent"); got != tt.logins {
+				t.Errorf("loginStudent called %d times, want %d\nactions: %v", got, tt.logins, mock.Actions())
+			}
+		})
+	}
+}
+
+// 会话过期后重新登录得到的 token 会保存下来
+func TestExpiredSessionSavesNewToken(t *testing.T) {
+	s, mock := newScenarioService(t, wisestutest.ScenarioExpiredSession, testConfig())
+	if _, err := s.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	store := s.store.(*memStore)
+	if store.token == "" || store.token != s.token {
+		t.Errorf("saved token = %q, service token = %q", store.token, s.token)
+	}
+	// 每次重新登录后都重试了失败的请求
+	for _, action := range []string{"getUnSigninList", "getSigninDetails", "updateLocationSignin", "getSigninSuccess"} {
+		if !slices.Contains(mock.Actions(), action) {
+			t.Errorf("%s was never called", action)
+		}
+	}
+}
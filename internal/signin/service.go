@@ -1,9 +1,9 @@
 package signin
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -12,30 +12,216 @@ import (
 	"zhxg-signin/internal/client"
 	"zhxg-signin/internal/config"
 	"zhxg-signin/internal/logger"
+	"zhxg-signin/internal/metrics"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/wisestu"
 )
 
 // Service 封装了签到服务的所有逻辑
 type Service struct {
-	cfg        config.Config
-	httpClient *client.HTTPClient
-	llmClient  *captcha.LLMClient
-	log        *zap.Logger
-	token      string
+	cfg      config.Config
+	api      APIClient
+	solver   Solver
+	clock    Clock
+	log      Logger
+	store    SessionStore    // 为 nil 时不持久化登录会话
+	sessions SessionRecorder // 为 nil 时不上报会话检查结果
+	token    string
+	student  *wisestu.Student // 最近一次获取的学生信息，登录失效时清空
+	closed   map[[2]int]bool  // 已结束的签到任务，按 ID 和批次号记录，不再尝试签到
+
+	strategies map[string]Strategy // 签到方式注册表，按 signin.flows 中的名称查找
+	attempts   int                 // 本次运行中识别验证码的次数
 }
 
-// NewService 创建一个新的签到服务
-func NewService(cfg config.Config) *Service {
-	return &Service{
-		cfg:        cfg,
-		httpClient: client.NewHTTPClient(cfg.SignIn.BaseURL, cfg.Logging.Debug),
-		llmClient:  captcha.NewLLMClient(cfg.LLM, cfg.Logging.Debug),
+// NewService 创建一个新的签到服务，store 不为 nil 时会持久化并复用登录会话。
+// 默认使用 client.HTTPClient、captcha.LLMClient 和全局日志，可通过 opts 替换
+func NewService(cfg config.Config, store *state.Store, opts ...Option) *Service {
+	s := &Service{
+		cfg:    cfg,
+		clock:  realClock{},
+		closed: make(map[[2]int]bool),
+
+		strategies: builtinStrategies(cfg.SignIn.Strategies),
 		log:        logger.GetLogger(),
 	}
+	if store != nil {
+		s.store = store
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	account := cfg.User.Username
+	if s.api == nil {
+		httpClient := client.NewHTTPClient(cfg.SignIn.BaseURL, cfg.Logging.Debug)
+		httpClient.SetObserver(func(action string, d time.Duration, err error) {
+			metrics.ObserveAPI(account, action, d, err)
+		})
+		s.api = wisestu.New(httpClient)
+	}
+	if s.solver == nil {
+		llmClient := captcha.NewLLMClient(cfg.LLM, cfg.Logging.Debug)
+		llmClient.SetObserver(func(d time.Duration, status string, usage captcha.Usage) {
+			metrics.ObserveLLM(account, cfg.LLM.Model, status, d, usage.PromptTokens, usage.CompletionTokens)
+		})
+		s.solver = llmClient
+	}
+	if s.store != nil {
+		s.token = s.store.Token()
+	}
+	return s
 }
 
-// Run 执行完整的签到流程
-func (s *Service) Run() error {
+// Run 执行完整的签到流程，返回的 Result 总是非 nil，其 Err 与返回的 error 相同
+func (s *Service) Run() (*Result, error) {
+	return s.run(nil)
+}
+
+// RunTasks 从 tasks 中选择任务签到，tasks 应来自刚刚调用的 PendingTasks，
+// 因此不再重新检查会话和获取未签到列表。tasks 为空时与 Run 相同
+func (s *Service) RunTasks(tasks []SigninTask) (*Result, error) {
+	return s.run(tasks)
+}
+
+func (s *Service) run(tasks []SigninTask) (*Result, error) {
 	s.log.Info("开始签到流程")
+	loc := s.Location()
+
+	res := &Result{
+		Account:   s.cfg.User.Username,
+		Stage:     StageLogin,
+		Solver:    s.solver.Name(),
+		Lng:       loc.Longitude,
+		Lat:       loc.Latitude,
+		StartedAt: s.clock.Now(),
+	}
+	s.attempts = 0
+
+	var err error
+	if len(tasks) == 0 {
+		err = s.ensureLogin()
+	}
+	if err == nil {
+		res.Stage = StageProfile
+		err = s.checkIdentity(res)
+	}
+	if err == nil {
+		// 阶段三：执行签到
+		err = s.performSignInFlow(res, tasks)
+	}
+
+	res.FinishedAt = s.clock.Now()
+	res.CaptchaAttempts = s.attempts
+	res.Err = err
+	res.ErrorClass = ClassifyError(err, res.Stage)
+	return res, err
+}
+
+// transportWrapper 是支持包装底层 HTTP transport 的客户端
+type transportWrapper interface {
+	WrapTransport(wrap func(http.RoundTripper) http.RoundTripper)
+}
+
+// WrapTransports 分别包装访问智慧学工和 LLM API 的 HTTP transport，
+// 通过选项注入的客户端不支持包装时忽略
+func (s *Service) WrapTransports(api, llm func(http.RoundTripper) http.RoundTripper) {
+	if c, ok := s.api.(transportWrapper); ok {
+		c.WrapTransport(api)
+	}
+	if c, ok := s.solver.(transportWrapper); ok {
+		c.WrapTransport(llm)
+	}
+}
+
+// Location 返回签到使用的位置，状态文件中的覆盖优先于配置文件
+func (s *Service) Location() config.LocationConfig {
+	if s.store != nil {
+		if loc, ok := s.store.Location(s.cfg.User.Username); ok {
+			return config.LocationConfig{Longitude: loc.Longitude, Latitude: loc.Latitude}
+		}
+	}
+	return s.cfg.Location
+}
+
+// PendingTasks 返回当前未签到的任务列表，必要时先登录
+func (s *Service) PendingTasks() ([]SigninTask, error) {
+	if err := s.ensureLogin(); err != nil {
+		return nil, err
+	}
+	var tasks []SigninTask
+	err := s.withSession(func() (err error) {
+		tasks, err = s.getUnSigninList()
+		return err
+	})
+	return tasks, err
+}
+
+// Profile 返回当前账号的学生信息，必要时先登录
+func (s *Service) Profile() (*wisestu.Student, error) {
+	if err := s.ensureLogin(); err != nil {
+		return nil, err
+	}
+	return s.studentInfo()
+}
+
+// studentInfo 返回检查登录状态时已获取的学生信息，刚刚重新登录过时再查询一次
+func (s *Service) studentInfo() (*wisestu.Student, error) {
+	if s.student == nil {
+		student, err := s.api.QueryMyStuInfo()
+		if err != nil {
+			return nil, fmt.Errorf("获取学生信息失败: %w", err)
+		}
+		s.student = student
+	}
+	return s.student, nil
+}
+
+// checkIdentity 获取学生信息并记录真实姓名，配置了 user.real_name 时核对姓名，
+// 避免以错误的身份提交签到。调用前会话应已有效，这里不再检查登录状态
+func (s *Service) checkIdentity(res *Result) error {
+	student, err := s.studentInfo()
+	if err != nil {
+		if s.cfg.User.RealName != "" {
+			return err
+		}
+		s.log.Warn("获取学生信息失败，跳过身份核对", zap.Error(err))
+		return nil
+	}
+
+	res.Name = student.RealName
+	if want := s.cfg.User.RealName; want != "" && student.RealName != want {
+		return fmt.Errorf("%w: 配置为 %s，实际为 %s (%s)", ErrIdentityMismatch, want, student.RealName, student.StuNo)
+	}
+	s.log.Info("当前登录学生", zap.String("name", student.RealName), zap.String("stuNo", student.StuNo))
+	return nil
+}
+
+// ProbeSession 检查保存的 token 是否仍然有效并上报结果，不会重新登录。
+// 没有保存的 token 时不做任何请求，留到下次签到时再登录
+func (s *Service) ProbeSession() error {
+	if s.token == "" {
+		return nil
+	}
+	s.api.SetAuthToken(s.token)
+	loggedIn, err := s.checkLoginStatus()
+	if err == nil && !loggedIn {
+		err = errors.New("保存的 Token 已失效，将在下次签到时重新登录")
+	}
+	s.recordSession(err)
+	return err
+}
+
+// recordSession 上报会话检查的结果
+func (s *Service) recordSession(err error) {
+	if s.sessions != nil {
+		s.sessions.RecordSession(s.cfg.User.Username, err)
+	}
+}
+
+// ensureLogin 检查当前会话是否有效，无效时执行登录
+func (s *Service) ensureLogin() error {
+	s.api.SetAuthToken(s.token)
 
 	// 阶段一：检查登录状态
 	loggedIn, err := s.checkLoginStatus()
@@ -45,255 +231,271 @@ func (s *Service) Run() error {
 
 	if loggedIn {
 		s.log.Info("Token 有效，已处于登录状态")
-	} else {
-		s.log.Info("Token 无效或不存在，需要登录")
-		// 阶段二：执行登录循环
-		token, err := s.login()
-		if err != nil {
-			s.log.Error("登录流程失败", zap.Error(err))
-			return err
-		}
-		s.token = token
-		s.log.Info("登录成功，获取到新的 Token")
+		s.recordSession(nil)
+		return nil
+	}
+
+	s.log.Info("Token 无效或不存在，需要登录")
+	// 阶段二：执行登录循环
+	return s.relogin()
+}
+
+// relogin 执行登录循环，成功后保存新的 token
+func (s *Service) relogin() error {
+	token, err := s.login()
+	s.recordSession(err)
+	if err != nil {
+		s.log.Error("登录流程失败", zap.Error(err))
+		return err
 	}
+	s.token = token
+	s.api.SetAuthToken(s.token)
+	s.log.Info("登录成功，获取到新的 Token")
 
-	s.httpClient.SetAuthToken(s.token)
+	if s.store != nil {
+		if err := s.store.SetToken(token); err != nil {
+			s.log.Warn("保存 Token 失败", zap.Error(err))
+		}
+	}
+	return nil
+}
 
-	// 阶段三：执行签到
-	return s.performSignInFlow()
+// withSession 执行需要登录的请求，会话在运行中途失效（Need Login）时重新登录并重试一次
+func (s *Service) withSession(fn func() error) error {
+	err := fn()
+	if code, ok := wisestu.CodeOf(err); !ok || code != wisestu.CodeNeedLogin {
+		return err
+	}
+	s.log.Warn("会话已失效，重新登录后重试", zap.Error(err))
+	s.student = nil
+	if err := s.relogin(); err != nil {
+		return fmt.Errorf("会话失效后重新登录失败: %w", err)
+	}
+	return fn()
 }
 
 // checkLoginStatus 检查当前 token 是否有效
 func (s *Service) checkLoginStatus() (bool, error) {
 	// 即使 token 为空，也尝试请求，让服务器决定状态
-	// s.httpClient.R() 会自动附加 s.token (如果存在)
-	resp, err := s.httpClient.R().
-		SetBody(`{"action":"queryMyStuInfo"}`).
-		Post("/dnui/api/student/basic/stuInfo.api")
-
+	student, err := s.api.QueryMyStuInfo()
+	s.student = student
+	if code, ok := wisestu.CodeOf(err); ok {
+		s.log.Info("检查登录状态响应", zap.Int("code", code))
+		return false, nil
+	}
 	if err != nil {
 		s.log.Warn("检查登录状态请求失败", zap.Error(err))
 		return false, err
 	}
-
-	var baseResp BaseResponse
-	if err := json.Unmarshal(resp.Body(), &baseResp); err != nil {
-		s.log.Warn("解析登录状态响应失败", zap.Error(err))
-		return false, fmt.Errorf("解析登录状态响应失败: %w", err)
-	}
-
-	s.log.Info("检查登录状态响应", zap.Int("code", baseResp.Code), zap.String("message", baseResp.Message))
-	// code 为 0 表示已登录
-	return baseResp.Code == 0, nil
+	return true, nil
 }
 
 // login 执行带重试的登录循环
 func (s *Service) login() (string, error) {
 	s.token = "" // 循环开始前清除 token
+	s.api.SetAuthToken("")
 
 	var lastErr error
 	for i := 0; i < 5; i++ {
 		s.log.Info("开始登录尝试", zap.Int("attempt", i+1))
 
 		// 1. 获取验证码
-		verifResp, err := s.getVerification()
+		verif, err := s.api.QueryVerificationQuestion()
 		if err != nil {
 			lastErr = fmt.Errorf("第 %d 次尝试：获取验证码失败: %w", i+1, err)
 			s.log.Warn(lastErr.Error())
-			time.Sleep(s.cfg.SignIn.RetryInterval)
+			s.clock.Sleep(s.cfg.SignIn.RetryInterval)
 			continue
 		}
 
 		// 2. 识别验证码
-		answer, err := s.llmClient.SolveCaptcha(verifResp.VerificationImage)
+		s.attempts++
+		answer, err := s.solver.SolveCaptcha(verif.VerificationImage)
 		if err != nil {
+			metrics.ObserveCaptcha(s.cfg.User.Username, s.solver.Name(), "error")
 			lastErr = fmt.Errorf("第 %d 次尝试：识别验证码失败: %w", i+1, err)
 			s.log.Warn(lastErr.Error())
-			time.Sleep(s.cfg.SignIn.RetryInterval)
+			s.clock.Sleep(s.cfg.SignIn.RetryInterval)
 			continue
 		}
 		s.log.Info("验证码识别结果", zap.Int("answer", answer))
 
 		// 3. 尝试登录
-		reqBody := LoginRequest{
-			Action:             "loginStudent",
-			VerificationID:     verifResp.VerificationID,
-			VerificationImage:  verifResp.VerificationImage,
-			VerificationAnswer: strconv.Itoa(answer),
-			LoginName:          s.cfg.User.Username,
-			Password:           s.cfg.User.Password,
-			ClientType:         "App",
-			ClientVer:          "2.0.1",
-			ClientExtra:        `{"available":true,"platform":"Android","version":"15","uuid":"","cordova":"8.1.0","model":"22081212C","manufacturer":"Xiaomi","isVirtual":false,"serial":"unknown"}`,
+		token, err := s.api.LoginStudent(s.cfg.User.Username, s.cfg.User.Password, verif, strconv.Itoa(answer))
+		code, isAPIErr := wisestu.CodeOf(err)
+		if err == nil || errors.Is(err, wisestu.ErrNoToken) || isAPIErr {
+			s.log.Info("登录响应", zap.Int("code", code))
+			s.observeLogin(code)
 		}
 
-		resp, err := s.httpClient.R().
-			SetBody(reqBody).
-			Post("/dnui/api/user/loginout.api")
-
-		if err != nil {
-			lastErr = fmt.Errorf("第 %d 次尝试：登录请求失败: %w", i+1, err)
-			s.log.Warn(lastErr.Error())
-			time.Sleep(s.cfg.SignIn.RetryInterval)
-			continue
-		}
-
-		var baseResp BaseResponse
-		if err := json.Unmarshal(resp.Body(), &baseResp); err != nil {
-			lastErr = fmt.Errorf("第 %d 次尝试：解析登录响应失败: %w", i+1, err)
-			s.log.Warn(lastErr.Error())
-			time.Sleep(s.cfg.SignIn.RetryInterval)
-			continue
-		}
-
-		s.log.Info("登录响应", zap.Int("code", baseResp.Code), zap.String("message", baseResp.Message))
-
 		// 4. 判定循环退出条件
-		if baseResp.Code == 0 {
-			token := resp.Header().Get("token")
-			if token == "" {
-				lastErr = errors.New("登录成功但未在响应头中找到 token")
-				s.log.Warn(lastErr.Error())
-				time.Sleep(s.cfg.SignIn.RetryInterval)
-				continue // 虽然 code 为 0，但没 token 还是得重试
-			}
+		switch {
+		case err == nil:
 			return token, nil // 成功获取 token，退出循环
+		case code == wisestu.CodeWrongPassword:
+			return "", fmt.Errorf("登录失败：%w (code: 1002)", ErrWrongPassword)
+		case errors.Is(err, wisestu.ErrNoToken):
+			// 虽然 code 为 0，但没 token 还是得重试
+			lastErr = err
+		case isAPIErr:
+			lastErr = fmt.Errorf("第 %d 次尝试：登录失败，%w", i+1, err)
+		default:
+			lastErr = fmt.Errorf("第 %d 次尝试：登录请求失败: %w", i+1, err)
 		}
-
-		if baseResp.Code == 1002 {
-			return "", fmt.Errorf("登录失败：密码错误 (code: 1002)")
-		}
-
-		lastErr = fmt.Errorf("第 %d 次尝试：登录失败，code: %d, message: %s", i+1, baseResp.Code, baseResp.Message)
 		s.log.Warn(lastErr.Error())
-		time.Sleep(s.cfg.SignIn.RetryInterval)
+		s.clock.Sleep(s.cfg.SignIn.RetryInterval)
 	}
 
 	return "", fmt.Errorf("登录失败，已达到最大重试次数 (5次): %w", lastErr)
 }
 
-func (s *Service) getVerification() (*VerificationResponse, error) {
-	var result VerificationResponse
-	resp, err := s.httpClient.R().
-		SetBody(map[string]string{"action": "queryVerificationQuestion", "client_type": "App"}).
-		SetResult(&result).
-		Post("/dnui/api/user/loginout.api")
+// observeLogin 根据登录响应码记录登录和验证码识别指标
+func (s *Service) observeLogin(code int) {
+	account := s.cfg.User.Username
+	metrics.ObserveLogin(account, code == wisestu.CodeOK)
 
-	if err != nil {
-		return nil, err
+	// 1002 表示密码错误，说明验证码已通过校验
+	outcome := "rejected"
+	if code == wisestu.CodeOK || code == wisestu.CodeWrongPassword {
+		outcome = "accepted"
 	}
-	if resp.IsError() {
-		return nil, fmt.Errorf("获取验证码请求失败: %s", resp.Status())
-	}
-	return &result, nil
+	metrics.ObserveCaptcha(account, s.solver.Name(), outcome)
 }
 
-func (s *Service) performSignInFlow() error {
-	// 获取未签到列表
-	var listResp UnSigninListResponse
-	resp, err := s.httpClient.R().
-		SetBody(map[string]interface{}{"action": "getUnSigninList", "pageSize": 10, "pageNum": 1}).
-		SetResult(&listResp).
-		Post("/dnui/api/student/signin/signin.api")
-
+// getUnSigninList 获取未签到列表
+func (s *Service) getUnSigninList() ([]SigninTask, error) {
+	all, err := s.api.GetUnSigninList(1, 10)
 	if err != nil {
-		return fmt.Errorf("获取签到列表请求失败: %w", err)
+		return nil, fmt.Errorf("获取签到列表失败: %w", err)
 	}
 
-	var baseResp BaseResponse
-	if err := json.Unmarshal(resp.Body(), &baseResp); err != nil {
-		return fmt.Errorf("解析签到列表响应失败: %w", err)
+	// 已结束的任务仍会留在未签到列表中，跳过它们以免反复尝试
+	tasks := all[:0:0]
+	for _, task := range all {
+		if s.closed[[2]int{task.ID, task.BatchNo}] {
+			s.log.Debug("跳过已结束的签到任务", zap.Int("signinID", task.ID), zap.Int("batchNo", task.BatchNo))
+			continue
+		}
+		tasks = append(tasks, task)
 	}
 
-	if baseResp.Code != 0 {
-		return fmt.Errorf("获取签到列表失败, code: %d, message: %s", baseResp.Code, baseResp.Message)
+	metrics.SetPendingTasks(s.cfg.User.Username, len(tasks))
+	return tasks, nil
+}
+
+// performSignInFlow 从 tasks 中选择任务签到，tasks 为空时先获取未签到列表
+func (s *Service) performSignInFlow(res *Result, tasks []SigninTask) error {
+	res.Stage = StageList
+	if len(tasks) == 0 {
+		err := s.withSession(func() (err error) {
+			tasks, err = s.getUnSigninList()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	res.Pending = len(tasks)
+	for _, task := range tasks {
+		res.TaskIDs = append(res.TaskIDs, task.ID)
+		res.BatchNos = append(res.BatchNos, task.BatchNo)
 	}
 
-	if len(listResp.Result.List) == 0 {
+	if len(tasks) == 0 {
 		s.log.Info("没有需要签到的任务")
+		res.Stage = StageDone
 		return nil
 	}
 
-	var signinID int
-	var batchNo int
-	foundInternship := false
-
-	// 遍历列表查找 "实习" 签到任务
-	for _, item := range listResp.Result.List {
-		if item.SigninTypeName == "实习" {
-			signinID = item.ID
-			batchNo = item.BatchNo
-			foundInternship = true
-			s.log.Info("找到 '实习' 签到任务", zap.Int("signinID", signinID), zap.Int("batchNo", batchNo))
-			break
-		}
-	}
-
-	if !foundInternship {
-		s.log.Warn("未找到 '实习' 签到任务，将使用列表中的第一个任务进行签到")
-		signinID = listResp.Result.List[0].ID
-		batchNo = listResp.Result.List[0].BatchNo // 假设第一个任务也有 batch_no
-		s.log.Info("使用第一个签到任务", zap.Int("signinID", signinID), zap.Int("batchNo", batchNo))
+	task, ok := s.selectTask(tasks)
+	if !ok {
+		s.log.Info("待签到任务的签到方式均为 skip，本次不签到", zap.Int("pending", len(tasks)))
+		res.Stage = StageDone
+		return nil
 	}
+	signinID, batchNo := task.ID, task.BatchNo
+	res.TaskType, res.SigninID, res.BatchNo = task.SigninTypeName, signinID, batchNo
+	s.log.Info("选择签到任务", zap.String("taskType", task.SigninTypeName), zap.Int("signinID", signinID), zap.Int("batchNo", batchNo))
 
 	// 1. 调用“进入签到”接口
-	if err := s.getSigninDetails(signinID, batchNo); err != nil {
+	res.Stage = StageDetails
+	var details *wisestu.SigninDetails
+	err := s.withSession(func() (err error) {
+		details, err = s.getSigninDetails(signinID, batchNo)
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("进入签到失败: %w", err)
 	}
 
-	// 2. 调用“updateLocationSignin”接口
-	if err := s.updateLocationSignin(signinID, batchNo); err != nil {
-		return fmt.Errorf("提交位置签到失败: %w", err)
+	res.Stage = StageCheck
+	if err := s.checkDetails(details, res); err != nil {
+		return err
+	}
+
+	// 2. 按任务类型选取签到方式并提交签到
+	if err := s.withSession(func() error { return s.submit(task, details, res) }); err != nil {
+		return err
 	}
 
 	// 3. 调用“签到情况”接口
-	if err := s.getSigninSuccess(signinID, batchNo); err != nil {
+	res.Stage = StageConfirm
+	if err := s.withSession(func() error { return s.getSigninSuccess(signinID, batchNo) }); err != nil {
 		return fmt.Errorf("获取签到情况失败: %w", err)
 	}
 
+	res.Stage = StageDone
 	s.log.Info("签到流程执行完毕")
 	return nil
 }
 
 // getSigninDetails 调用“进入签到”接口
-func (s *Service) getSigninDetails(signinID, batchNo int) error {
-	reqBody := GetSigninDetailsRequest{
-		Action:  "getSigninDetails",
-		ID:      signinID,
-		BatchNo: batchNo,
-	}
-
-	resp, err := s.httpClient.R().
-		SetBody(reqBody).
-		Post("/dnui/api/student/signin/signin.api")
-
+func (s *Service) getSigninDetails(signinID, batchNo int) (*wisestu.SigninDetails, error) {
+	details, err := s.api.GetSigninDetails(signinID, batchNo)
 	if err != nil {
-		return fmt.Errorf("进入签到请求失败: %w", err)
+		return nil, err
 	}
+	// 签到详情的字段名是推测的，记录原始响应以便与解析结果对照
+	s.log.Debug("签到详情原始响应", zap.ByteString("result", details.Raw))
 
-	var baseResp BaseResponse
-	if err := json.Unmarshal(resp.Body(), &baseResp); err != nil {
-		return fmt.Errorf("解析进入签到响应失败: %w", err)
-	}
+	s.log.Info("成功进入签到", zap.Int("signinID", signinID), zap.Int("batchNo", batchNo),
+		zap.Time("start", details.StartTime.Time), zap.Time("end", details.EndTime.Time),
+		zap.Float64("radius", details.Radius), zap.Bool("needPhoto", bool(details.NeedPhoto)))
+	return details, nil
+}
 
-	if baseResp.Code != 0 {
-		return fmt.Errorf("进入签到失败, code: %d, message: %s", baseResp.Code, baseResp.Message)
+// checkDetails 在提交前核对签到时间和签到范围，不满足时返回的错误重试也不会成功
+func (s *Service) checkDetails(d *wisestu.SigninDetails, res *Result) error {
+	now := s.clock.Now()
+	const layout = "2006-01-02 15:04:05"
+	switch {
+	case !d.StartTime.IsZero() && now.Before(d.StartTime.Time):
+		res.OpensAt = d.StartTime.Time
+		return fmt.Errorf("%w，开始时间为 %s", ErrNotOpen, d.StartTime.Format(layout))
+	case !d.EndTime.IsZero() && now.After(d.EndTime.Time):
+		s.closed[[2]int{res.SigninID, res.BatchNo}] = true
+		return fmt.Errorf("%w，结束时间为 %s", ErrClosed, d.EndTime.Format(layout))
 	}
 
-	s.log.Info("成功进入签到", zap.Int("signinID", signinID), zap.Int("batchNo", batchNo))
+	if d.HasFence() {
+		loc := s.Location()
+		if dist := d.DistanceTo(loc.Longitude, loc.Latitude); dist > d.Radius {
+			return fmt.Errorf("%w：距离签到中心 %.0f 米，允许范围为 %.0f 米", ErrOutsideFence, dist, d.Radius)
+		}
+	}
 	return nil
 }
 
-// updateLocationSignin 调用“updateLocationSignin”接口
-func (s *Service) updateLocationSignin(signinID, batchNo int) error {
-	// 构建 signin_location 字段的 JSON 字符串
-	signinLocation := SigninLocation{
-		Point: SigninLocationPoint{
-			Lng: s.cfg.Location.Longitude,
-			Lat: s.cfg.Location.Latitude,
+// signinLocation 返回提交签到时使用的位置和地址
+func (s *Service) signinLocation() wisestu.SigninLocation {
+	loc := s.Location()
+	return wisestu.SigninLocation{
+		Point: wisestu.SigninLocationPoint{
+			Lng: loc.Longitude,
+			Lat: loc.Latitude,
 		},
 		Address: "柳州市鱼峰区葡萄山路7号科技楼", // 从 Apifox CLI 中获取的固定地址
-		AddressComponents: SigninLocationAddressComponents{
+		AddressComponents: wisestu.SigninLocationAddressComponents{
 			StreetNumber: "7号",
 			Street:       "葡萄山路",
 			District:     "鱼峰区",
@@ -301,66 +503,14 @@ func (s *Service) updateLocationSignin(signinID, batchNo int) error {
 			Province:     "广西壮族自治区",
 		},
 	}
-
-	signinLocationJSON, err := json.Marshal(signinLocation)
-	if err != nil {
-		return fmt.Errorf("序列化 signin_location 失败: %w", err)
-	}
-
-	reqBody := UpdateLocationSigninRequest{
-		Action:         "updateLocationSignin",
-		ID:             signinID,
-		BatchNo:        batchNo,
-		SigninLocation: string(signinLocationJSON),
-		OutsideFlag:    "1", // 从 Apifox CLI 中获取的固定值
-	}
-
-	resp, err := s.httpClient.R().
-		SetBody(reqBody).
-		Post("/dnui/api/student/signin/signin.api")
-
-	if err != nil {
-		return fmt.Errorf("提交位置签到请求失败: %w", err)
-	}
-
-	var baseResp BaseResponse
-	if err := json.Unmarshal(resp.Body(), &baseResp); err != nil {
-		return fmt.Errorf("解析位置签到响应失败: %w", err)
-	}
-
-	if baseResp.Code != 0 {
-		return fmt.Errorf("位置签到失败, code: %d, message: %s", baseResp.Code, baseResp.Message)
-	}
-
-	s.log.Info("位置签到成功", zap.Int("signinID", signinID), zap.Int("batchNo", batchNo))
-	return nil
 }
 
 // getSigninSuccess 调用“签到情况”接口
 func (s *Service) getSigninSuccess(signinID, batchNo int) error {
-	reqBody := GetSigninSuccessRequest{
-		Action:  "getSigninSuccess",
-		ID:      signinID,
-		BatchNo: batchNo,
-	}
-
-	resp, err := s.httpClient.R().
-		SetBody(reqBody).
-		Post("/dnui/api/student/signin/signin.api")
-
-	if err != nil {
-		return fmt.Errorf("获取签到情况请求失败: %w", err)
-	}
-
-	var baseResp BaseResponse
-	if err := json.Unmarshal(resp.Body(), &baseResp); err != nil {
-		return fmt.Errorf("解析签到情况响应失败: %w", err)
-	}
-
-	if baseResp.Code != 0 {
-		return fmt.Errorf("获取签到情况失败, code: %d, message: %s", baseResp.Code, baseResp.Message)
+	if err := s.api.GetSigninSuccess(signinID, batchNo); err != nil {
+		return err
 	}
 
 	s.log.Info("成功获取签到情况", zap.Int("signinID", signinID), zap.Int("batchNo", batchNo))
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,408 @@
+package signin
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/state"
+	"zhxg-signin/internal/wisestu"
+)
+
+const validToken = "fake-token"
+
+// fakeAPI 是脚本化的智慧学工接口，按顺序返回 logins 中的登录结果，用完后重复最后一个
+type fakeAPI struct {
+	token   string
+	logins  []error // nil 表示登录成功并返回 validToken
+	student wisestu.Student
+	tasks   []wisestu.SigninTask
+	details wisestu.SigninDetails
+	calls   []string
+}
+
+func (f *fakeAPI) SetAuthToken(token string) { f.token = token }
+
+func (f *fakeAPI) QueryVerificationQuestion() (*wisestu.Verification, error) {
+	f.calls = append(f.calls, "queryVerificationQuestion")
+	return &wisestu.Verification{VerificationID: "v1", VerificationImage: "aW1hZ2U="}, nil
+}
+
+func (f *fakeAPI) LoginStudent(loginName, password string, v *wisestu.Verification, answer string) (string, error) {
+	f.calls = append(f.calls, "loginStudent")
+	err := f.logins[0]
+	if len(f.logins) > 1 {
+		f.logins = f.logins[1:]
+	}
+	if err != nil {
+		return "", err
+	}
+	return validToken, nil
+}
+
+func (f *fakeAPI) QueryMyStuInfo() (*wisestu.Student, error) {
+	f.calls = append(f.calls, "queryMyStuInfo")
+	if f.token != validToken {
+		return nil, apiError(wisestu.CodeNeedLogin)
+	}
+	student := f.student
+	return &student, nil
+}
+
+func (f *fakeAPI) GetUnSigninList(pageNum, pageSize int) ([]wisestu.SigninTask, error) {
+	f.calls = append(f.calls, "getUnSigninList")
+	return f.tasks, nil
+}
+
+func (f *fakeAPI) GetSigninDetails(id, batchNo int) (*wisestu.SigninDetails, error) {
+	f.calls = append(f.calls, "getSigninDetails")
+	d := f.details
+	d.ID, d.BatchNo = id, batchNo
+	return &d, nil
+}
+
+func (f *fakeAPI) CheckOutsideFlag(id int, lng, lat float64) (bool, error) {
+	f.calls = append(f.calls, "checkOutsideFlag")
+	return false, nil
+}
+
+func (f *fakeAPI) UpdateLocationSignin(id, batchNo int, loc wisestu.SigninLocation, outsideFlag string) error {
+	f.calls = append(f.calls, "updateLocationSignin")
+	return nil
+}
+
+func (f *fakeAPI) UploadSigninImage(id, batchNo int, fileName string, data []byte) (string, error) {
+	f.calls = append(f.calls, "uploadSigninImage")
+	return "/photos/1.jpg", nil
+}
+
+func (f *fakeAPI) UpdatePhotoSignin(id, batchNo int, photoURL string, loc wisestu.SigninLocation) error {
+	f.calls = append(f.calls, "updatePhotoSignin")
+	return nil
+}
+
+func (f *fakeAPI) GetSigninSuccess(id, batchNo int) error {
+	f.calls = append(f.calls, "getSigninSuccess")
+	return nil
+}
+
+// count 返回 action 被调用的次数
+func (f *fakeAPI) count(action string) int {
+	n := 0
+	for _, c := range f.calls {
+		if c == action {
+			n++
+		}
+	}
+	return n
+}
+
+func apiError(code int) error {
+	return &wisestu.APIError{Action: "fake", Code: code, Message: "fake"}
+}
+
+// fakeSolver 总是返回同一个答案
+type fakeSolver struct{}
+
+func (fakeSolver) Name() string                           { return "fake" }
+func (fakeSolver) SolveCaptcha(image string) (int, error) { return 3, nil }
+
+// fakeClock 停在固定时间，Sleep 只记录等待的时长
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.slept = append(c.slept, d) }
+
+// memStore 是内存中的会话存储
+type memStore struct{ token string }
+
+func (m *memStore) Token() string                                  { return m.token }
+func (m *memStore) SetToken(token string) error                    { m.token = token; return nil }
+func (m *memStore) Location(account string) (state.Location, bool) { return state.Location{}, false }
+
+var testNow = time.Date(2025, 7, 14, 8, 0, 0, 0, time.Local)
+
+func testConfig() config.Config {
+	return config.Config{
+		User:     config.UserConfig{Username: "20230001", Password: "password"},
+		Location: config.LocationConfig{Longitude: 109.4, Latitude: 24.3},
+		SignIn:   config.SignInConfig{RetryInterval: time.Second},
+	}
+}
+
+// newTestService 创建使用替身依赖的 Service
+func newTestService(cfg config.Config, api *fakeAPI, store *memStore) (*Service, *fakeClock) {
+	clock := &fakeClock{now: testNow}
+	s := NewService(cfg, nil,
+		WithAPIClient(api),
+		WithSolver(fakeSolver{}),
+		WithClock(clock),
+		WithSessionStore(store),
+		WithLogger(zap.NewNop()),
+	)
+	return s, clock
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name      string
+		logins    []error
+		wantClass string // 为空时要求登录成功
+		wantTries int
+	}{
+		{name: "success", logins: []error{nil}, wantTries: 1},
+		{name: "wrong password", logins: []error{apiError(wisestu.CodeWrongPassword)}, wantClass: ErrorClassWrongPassword, wantTries: 1},
+		{name: "no token", logins: []error{wisestu.ErrNoToken, nil}, wantTries: 2},
+		{name: "wrong captcha", logins: []error{apiError(wisestu.CodeWrongCaptcha), apiError(wisestu.CodeWrongCaptcha), nil}, wantTries: 3},
+		{name: "give up", logins: []error{apiError(wisestu.CodeWrongCaptcha)}, wantClass: ErrorClassLogin, wantTries: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{logins: tt.logins, student: wisestu.Student{RealName: "张三"}}
+			store := &memStore{}
+			s, clock := newTestService(testConfig(), api, store)
+
+			res, err := s.Run()
+			if (err != nil) != (tt.wantClass != "") {
+				t.Fatalf("Run error = %v, want class %q", err, tt.wantClass)
+			}
+			if res.ErrorClass != tt.wantClass {
+				t.Errorf("ErrorClass = %q, want %q", res.ErrorClass, tt.wantClass)
+			}
+			if n := api.count("loginStudent"); n != tt.wantTries || res.CaptchaAttempts != tt.wantTries {
+				t.Errorf("logged in %d times with %d captcha attempts, want %d", n, res.CaptchaAttempts, tt.wantTries)
+			}
+
+			// 每次需要重试的失败后等待一次重试间隔，密码错误不重试
+			retries := tt.wantTries - 1
+			if tt.wantClass == ErrorClassLogin {
+				retries = tt.wantTries
+			}
+			if len(clock.slept) != retries {
+				t.Errorf("slept %d times, want %d", len(clock.slept), retries)
+			}
+
+			wantToken := ""
+			if tt.wantClass == "" {
+				wantToken = validToken
+			}
+			if store.token != wantToken {
+				t.Errorf("saved token %q, want %q", store.token, wantToken)
+			}
+		})
+	}
+}
+
+func TestSavedSessionSkipsLogin(t *testing.T) {
+	api := &fakeAPI{logins: []error{nil}}
+	s, _ := newTestService(testConfig(), api, &memStore{token: validToken})
+
+	if _, err := s.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := api.count("loginStudent"); n != 0 {
+		t.Errorf("logged in %d times with a valid saved token", n)
+	}
+}
+
+// RunTasks 复用 PendingTasks 检查会话时获取的学生信息，不再重复请求
+func TestRunTasksReusesSession(t *testing.T) {
+	api := &fakeAPI{
+		logins:  []error{nil},
+		student: wisestu.Student{RealName: "张三", StuNo: "20230001"},
+		tasks:   []wisestu.SigninTask{{ID: 1, SigninTypeName: "实习", BatchNo: 10}},
+	}
+	s, _ := newTestService(testConfig(), api, &memStore{token: validToken})
+
+	tasks, err := s.PendingTasks()
+	if err != nil {
+		t.Fatalf("PendingTasks: %v", err)
+	}
+	res, err := s.RunTasks(tasks)
+	if err != nil {
+		t.Fatalf("RunTasks: %v", err)
+	}
+	if res.Name != "张三" {
+		t.Errorf("Name = %q, want 张三", res.Name)
+	}
+	if n := api.count("queryMyStuInfo"); n != 1 {
+		t.Errorf("queryMyStuInfo called %d times, want 1", n)
+	}
+	if n := api.count("getUnSigninList"); n != 1 {
+		t.Errorf("getUnSigninList called %d times, want 1", n)
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	task := wisestu.SigninTask{ID: 1, SigninTypeName: "实习", BatchNo: 10}
+	late := wisestu.SigninTask{ID: 2, SigninTypeName: "晚归", BatchNo: 20}
+
+	tests := []struct {
+		name      string
+		config    func(cfg *config.Config)
+		tasks     []wisestu.SigninTask
+		details   wisestu.SigninDetails
+		wantErr   error // 为 nil 时要求签到流程成功
+		wantClass string
+		wantStage string
+		wantCalls []string // 检查会话之后的调用，学生信息复用检查会话时的结果
+		submitted bool     // 是否实际提交了签到
+	}{
+		{
+			name:      "success",
+			tasks:     []wisestu.SigninTask{task},
+			wantStage: StageDone,
+			wantCalls: []string{"getUnSigninList", "getSigninDetails", "updateLocationSignin", "getSigninSuccess"},
+			submitted: true,
+		},
+		{
+			name:      "empty list",
+			wantStage: StageDone,
+			wantCalls: []string{"getUnSigninList"},
+		},
+		{
+			name:      "skip only",
+			config:    func(cfg *config.Config) { cfg.SignIn.Flows = map[string]string{"晚归": StrategySkip} },
+			tasks:     []wisestu.SigninTask{late},
+			wantStage: StageDone,
+			wantCalls: []string{"getUnSigninList"},
+		},
+		{
+			name:      "not open",
+			tasks:     []wisestu.SigninTask{task},
+			details:   wisestu.SigninDetails{StartTime: wisestu.Time{Time: testNow.Add(time.Hour)}},
+			wantErr:   ErrNotOpen,
+			wantClass: ErrorClassNotOpen,
+			wantStage: StageCheck,
+			wantCalls: []string{"getUnSigninList", "getSigninDetails"},
+		},
+		{
+			name:      "closed",
+			tasks:     []wisestu.SigninTask{task},
+			details:   wisestu.SigninDetails{EndTime: wisestu.Time{Time: testNow.Add(-time.Hour)}},
+			wantErr:   ErrClosed,
+			wantClass: ErrorClassClosed,
+			wantStage: StageCheck,
+			wantCalls: []string{"getUnSigninList", "getSigninDetails"},
+		},
+		{
+			name:  "outside fence",
+			tasks: []wisestu.SigninTask{task},
+			// 围栏中心在北京，配置的位置在柳州
+			details:   wisestu.SigninDetails{Lng: 116.4, Lat: 39.9, Radius: 500},
+			wantErr:   ErrOutsideFence,
+			wantClass: ErrorClassOutsideFence,
+			wantStage: StageCheck,
+			wantCalls: []string{"getUnSigninList", "getSigninDetails"},
+		},
+		{
+			name:      "identity mismatch",
+			config:    func(cfg *config.Config) { cfg.User.RealName = "李四" },
+			tasks:     []wisestu.SigninTask{task},
+			wantErr:   ErrIdentityMismatch,
+			wantClass: ErrorClassIdentity,
+			wantStage: StageProfile,
+			wantCalls: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tt.config != nil {
+				tt.config(&cfg)
+			}
+			api := &fakeAPI{
+				logins:  []error{nil},
+				student: wisestu.Student{RealName: "张三", StuNo: "20230001"},
+				tasks:   tt.tasks,
+				details: tt.details,
+			}
+			s, _ := newTestService(cfg, api, &memStore{token: validToken})
+
+			res, err := s.Run()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run error = %v, want %v", err, tt.wantErr)
+			}
+			if res.ErrorClass != tt.wantClass || res.Stage != tt.wantStage {
+				t.Errorf("ErrorClass = %q, Stage = %q, want %q, %q", res.ErrorClass, res.Stage, tt.wantClass, tt.wantStage)
+			}
+			if res.Submitted() != tt.submitted {
+				t.Errorf("Submitted = %v, want %v", res.Submitted(), tt.submitted)
+			}
+			if calls := api.calls[1:]; api.calls[0] != "queryMyStuInfo" || !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want queryMyStuInfo then %v", api.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err   error
+		stage string
+		want  string
+	}{
+		{nil, StageDone, ""},
+		{fmt.Errorf("%w，开始时间为 2025-07-14 09:00:00", ErrNotOpen), StageCheck, ErrorClassNotOpen},
+		{fmt.Errorf("%w，结束时间为 2025-07-14 07:00:00", ErrClosed), StageCheck, ErrorClassClosed},
+		{fmt.Errorf("登录失败：%w (code: 1002)", ErrWrongPassword), StageLogin, ErrorClassWrongPassword},
+		{apiError(wisestu.CodeWrongCaptcha), StageLogin, ErrorClassLogin},
+		{apiError(wisestu.CodeNeedLogin), StageSubmit, ErrorClassSignin},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err, tt.stage); got != tt.want {
+			t.Errorf("ClassifyError(%v, %s) = %q, want %q", tt.err, tt.stage, got, tt.want)
+		}
+	}
+}
+
+// 尚未开始和已结束都不应在本次运行中重试；已结束的任务之后也不再进入签到
+func TestNotOpenAndClosedAreNotRetried(t *testing.T) {
+	task := wisestu.SigninTask{ID: 1, SigninTypeName: "实习", BatchNo: 10}
+
+	t.Run("not open", func(t *testing.T) {
+		opens := testNow.Add(time.Hour)
+		api := &fakeAPI{tasks: []wisestu.SigninTask{task}, details: wisestu.SigninDetails{StartTime: wisestu.Time{Time: opens}}}
+		s, clock := newTestService(testConfig(), api, &memStore{token: validToken})
+
+		res, _ := s.Run()
+		if res.ErrorClass != ErrorClassNotOpen || !res.OpensAt.Equal(opens) {
+			t.Errorf("ErrorClass = %q, OpensAt = %v, want %q, %v", res.ErrorClass, res.OpensAt, ErrorClassNotOpen, opens)
+		}
+		if n := api.count("getSigninDetails"); n != 1 || len(clock.slept) != 0 {
+			t.Errorf("entered %d times and slept %d times, want 1 and 0", n, len(clock.slept))
+		}
+
+		// 开始时间之后再次运行可以签到
+		clock.now = opens
+		if _, err := s.Run(); err != nil {
+			t.Errorf("Run after opening: %v", err)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		api := &fakeAPI{tasks: []wisestu.SigninTask{task}, details: wisestu.SigninDetails{EndTime: wisestu.Time{Time: testNow.Add(-time.Hour)}}}
+		s, clock := newTestService(testConfig(), api, &memStore{token: validToken})
+
+		if res, _ := s.Run(); res.ErrorClass != ErrorClassClosed {
+			t.Errorf("ErrorClass = %q, want %q", res.ErrorClass, ErrorClassClosed)
+		}
+		res, err := s.Run()
+		if err != nil || res.Pending != 0 {
+			t.Errorf("second run: Pending = %d, err = %v, want the closed task to be skipped", res.Pending, err)
+		}
+		if n := api.count("getSigninDetails"); n != 1 || len(clock.slept) != 0 {
+			t.Errorf("entered %d times and slept %d times, want 1 and 0", n, len(clock.slept))
+		}
+	})
+}
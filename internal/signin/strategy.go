@@ -0,0 +1,256 @@
+package signin
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/wisestu"
+)
+
+// 内置的签到方式，对应 signin.flows 和 signin.default_flow 中的取值
+const (
+	StrategyLocation = "location" // 提交位置签到
+	StrategyPhoto    = "photo"    // 上传照片后提交拍照签到，同时附带位置，实验性
+	StrategyClick    = "click"    // 点击签到，只上报当前坐标
+	StrategySkip     = "skip"     // 不签到
+	StrategyAuto     = "auto"     // 签到详情要求拍照时使用 photo，否则使用 location
+)
+
+// defaultFlows 是未配置 signin.flows 时的映射，与早期版本优先签到实习任务的行为一致
+var defaultFlows = map[string]string{"实习": StrategyLocation}
+
+// Submission 是提交一次签到所需的信息，调用 Strategy 前已进入签到并核对了签到时间和范围
+type Submission struct {
+	Task     SigninTask
+	Details  *wisestu.SigninDetails
+	Location wisestu.SigninLocation // 本次签到使用的位置和地址
+	API      APIClient
+	Log      Logger
+	Result   *Result // 策略可更新 Result.Stage 以标明失败的步骤
+}
+
+// Strategy 是一种签到方式，按任务类型从 Service 的注册表中选取
+type Strategy interface {
+	Submit(sub *Submission) error
+}
+
+// StrategyFunc 将普通函数适配为 Strategy
+type StrategyFunc func(sub *Submission) error
+
+// Submit 调用 f(sub)
+func (f StrategyFunc) Submit(sub *Submission) error {
+	return f(sub)
+}
+
+// WithStrategy 注册名为 name 的签到方式，可覆盖内置的签到方式。
+// 在 signin.flows 或 signin.default_flow 中使用 name 即可为任务类型启用它
+func WithStrategy(name string, st Strategy) Option {
+	return func(s *Service) { s.strategies[name] = st }
+}
+
+// builtinStrategies 返回按配置创建的内置签到方式
+func builtinStrategies(cfg config.StrategiesConfig) map[string]Strategy {
+	return map[string]Strategy{
+		StrategyLocation: locationStrategy{cfg: cfg.Location},
+		StrategyPhoto:    photoStrategy{cfg: cfg.Photo},
+		StrategyClick:    clickStrategy{cfg: cfg.Click},
+	}
+}
+
+// ValidateFlows 对照签到方式注册表检查 signin.flows 和 signin.default_flow，
+// 包括通过 WithStrategy 注册的签到方式
+func (s *Service) ValidateFlows() error {
+	names := make([]string, 0, len(s.strategies)+1)
+	for name := range s.strategies {
+		names = append(names, name)
+	}
+	names = append(names, StrategySkip)
+	slices.Sort(names)
+	known := strings.Join(names, "、")
+
+	var errs []error
+	for taskType, flow := range s.cfg.SignIn.Flows {
+		if _, ok := s.strategies[flow]; !ok && flow != StrategySkip {
+			errs = append(errs, fmt.Errorf("signin.flows 中 %s 的签到方式 %q 无效，应为 %s", taskType, flow, known))
+		}
+	}
+	if flow := s.cfg.SignIn.DefaultFlow; flow != "" && flow != StrategyAuto && flow != StrategySkip {
+		if _, ok := s.strategies[flow]; !ok {
+			errs = append(errs, fmt.Errorf("signin.default_flow %q 无效，应为 %s 或 %s", flow, StrategyAuto, known))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flowFor 返回任务类型配置的签到方式，未配置时使用 signin.default_flow
+func (s *Service) flowFor(taskType string) string {
+	flows := s.cfg.SignIn.Flows
+	if len(flows) == 0 {
+		flows = defaultFlows
+	}
+	if flow, ok := flows[taskType]; ok {
+		return flow
+	}
+	if s.cfg.SignIn.DefaultFlow != "" {
+		return s.cfg.SignIn.DefaultFlow
+	}
+	return StrategyAuto
+}
+
+// selectTask 选出本次签到的任务，优先选择在 signin.flows 中明确配置的类型，跳过签到方式为 skip 的任务
+func (s *Service) selectTask(tasks []SigninTask) (SigninTask, bool) {
+	flows := s.cfg.SignIn.Flows
+	if len(flows) == 0 {
+		flows = defaultFlows
+	}
+	for _, task := range tasks {
+		if flow, ok := flows[task.SigninTypeName]; ok && flow != StrategySkip {
+			return task, true
+		}
+	}
+	for _, task := range tasks {
+		if s.flowFor(task.SigninTypeName) != StrategySkip {
+			return task, true
+		}
+	}
+	return SigninTask{}, false
+}
+
+// submit 按任务类型选取签到方式并提交签到
+func (s *Service) submit(task SigninTask, d *wisestu.SigninDetails, res *Result) error {
+	res.Stage = StageSubmit
+	name := s.flowFor(task.SigninTypeName)
+	if name == StrategyAuto {
+		name = StrategyLocation
+		if d.NeedPhoto {
+			name = StrategyPhoto
+		}
+	}
+	st, ok := s.strategies[name]
+	if !ok {
+		return fmt.Errorf("任务类型 %s 配置了未知的签到方式 %q", task.SigninTypeName, name)
+	}
+
+	res.Strategy = name
+	s.log.Info("提交签到", zap.String("taskType", task.SigninTypeName), zap.String("strategy", name))
+	return st.Submit(&Submission{
+		Task:     task,
+		Details:  d,
+		Location: s.signinLocation(),
+		API:      s.api,
+		Log:      s.log,
+		Result:   res,
+	})
+}
+
+// locationStrategy 调用 updateLocationSignin 提交位置签到
+type locationStrategy struct {
+	cfg config.LocationStrategyConfig
+}
+
+func (l locationStrategy) Submit(sub *Submission) error {
+	// outside_flag 默认值从 Apifox CLI 中获取
+	flag := l.cfg.OutsideFlag
+	if flag == "" {
+		flag = "1"
+	}
+	if err := sub.API.UpdateLocationSignin(sub.Task.ID, sub.Task.BatchNo, sub.Location, flag); err != nil {
+		return fmt.Errorf("提交位置签到失败: %w", err)
+	}
+
+	sub.Log.Info("位置签到成功", zap.Int("signinID", sub.Task.ID), zap.Int("batchNo", sub.Task.BatchNo))
+	return nil
+}
+
+// clickStrategy 调用 checkOutsideFlag 完成点击签到
+type clickStrategy struct {
+	cfg config.ClickStrategyConfig
+}
+
+func (c clickStrategy) Submit(sub *Submission) error {
+	p := sub.Location.Point
+	outside, err := sub.API.CheckOutsideFlag(sub.Task.ID, p.Lng, p.Lat)
+	if err != nil {
+		return fmt.Errorf("点击签到失败: %w", err)
+	}
+	if outside && c.cfg.RequireInside {
+		return fmt.Errorf("%w：服务器判定当前位置在签到范围外", ErrOutsideFence)
+	}
+
+	sub.Log.Info("点击签到成功", zap.Int("signinID", sub.Task.ID), zap.Bool("outside", outside))
+	return nil
+}
+
+// photoExts 是从照片目录中选取的图片扩展名
+var photoExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// photoStrategy 上传照片后调用 updatePhotoSignin 提交拍照签到。
+// 这是实验性的签到方式：两个接口都是推测的，没有真实的拍照签到抓包可以对照，
+// 只在模拟服务上测试过，用于学校服务器时可能失败
+type photoStrategy struct {
+	cfg config.PhotoStrategyConfig
+}
+
+// candidates 返回配置的照片文件和照片目录中的图片
+func (p photoStrategy) candidates() ([]string, error) {
+	files := append([]string(nil), p.cfg.Files...)
+	if dir := p.cfg.Dir; dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("读取照片目录失败: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && photoExts[strings.ToLower(filepath.Ext(e.Name()))] {
+				files = append(files, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// pick 从候选照片中随机选取一张，避免每次上传同一张照片
+func (p photoStrategy) pick() (name string, data []byte, err error) {
+	files, err := p.candidates()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("%w：请配置 signin.strategies.photo.files 或 dir", ErrNoPhoto)
+	}
+
+	path := files[rand.Intn(len(files))]
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("读取照片失败: %w", err)
+	}
+	return filepath.Base(path), data, nil
+}
+
+func (p photoStrategy) Submit(sub *Submission) error {
+	sub.Log.Warn("拍照签到是实验性功能，接口尚未在学校服务器上验证，可能签到失败", zap.Int("signinID", sub.Task.ID))
+	sub.Result.Stage = StageUpload
+	name, data, err := p.pick()
+	if err != nil {
+		return err
+	}
+	url, err := sub.API.UploadSigninImage(sub.Task.ID, sub.Task.BatchNo, name, data)
+	if err != nil {
+		return fmt.Errorf("上传签到照片失败: %w", err)
+	}
+	sub.Log.Info("已上传签到照片", zap.String("file", name), zap.String("url", url))
+
+	sub.Result.Stage = StageSubmit
+	if err := sub.API.UpdatePhotoSignin(sub.Task.ID, sub.Task.BatchNo, url, sub.Location); err != nil {
+		return fmt.Errorf("提交拍照签到失败: %w", err)
+	}
+
+	sub.Log.Info("拍照签到成功", zap.Int("signinID", sub.Task.ID), zap.Int("batchNo", sub.Task.BatchNo))
+	return nil
+}
@@ -0,0 +1,150 @@
+package signin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"zhxg-signin/internal/client"
+	"zhxg-signin/internal/config"
+	"zhxg-signin/internal/wisestu"
+	"zhxg-signin/internal/wisestutest"
+)
+
+// mockSolver 直接从模拟服务查询验证码答案
+type mockSolver struct{ mock *wisestutest.Server }
+
+func (m mockSolver) Name() string { return "mock" }
+
+func (m mockSolver) SolveCaptcha(image string) (int, error) {
+	answer, ok := m.mock.AnswerImage(image)
+	if !ok {
+		return 0, errors.New("未知的验证码")
+	}
+	return answer, nil
+}
+
+// newScenarioService 创建访问模拟服务预置场景的 Service
+func newScenarioService(t *testing.T, scenario string, cfg config.Config) (*Service, *wisestutest.Server) {
+	t.Helper()
+	opts, err := wisestutest.Scenario(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock, srv := wisestutest.NewServer(opts)
+	t.Cleanup(srv.Close)
+
+	s := NewService(cfg, nil,
+		WithAPIClient(wisestu.New(client.NewHTTPClient(srv.URL, false))),
+		WithSolver(mockSolver{mock}),
+		WithClock(&fakeClock{now: testNow}),
+		WithSessionStore(&memStore{}),
+		WithLogger(zap.NewNop()),
+	)
+	return s, mock
+}
+
+// newStubService 创建访问模拟服务 photo 场景的 Service
+func newStubService(t *testing.T, photo config.PhotoStrategyConfig) (*Service, *wisestutest.Server) {
+	t.Helper()
+	cfg := testConfig()
+	cfg.SignIn.Strategies.Photo = photo
+	return newScenarioService(t, wisestutest.ScenarioPhoto, cfg)
+}
+
+// 拍照签到的接口是推测的，这里验证的是签到流程与模拟服务中的推测一致
+func TestPhotoStrategyAgainstStub(t *testing.T) {
+	dir := t.TempDir()
+	photo := []byte("\xff\xd8\xff\xe0 fake jpeg")
+	if err := os.WriteFile(filepath.Join(dir, "1.jpg"), photo, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 非图片文件不应被选中
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a photo"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, mock := newStubService(t, config.PhotoStrategyConfig{Dir: dir})
+
+	res, err := s.Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// 默认的 auto 在签到详情要求拍照时选择 photo
+	if res.Strategy != StrategyPhoto || res.Stage != StageDone {
+		t.Errorf("Strategy = %q, Stage = %q", res.Strategy, res.Stage)
+	}
+	if got, ok := mock.Photo(wisestutest.PhotoTask.ID); !ok || string(got) != string(photo) {
+		t.Errorf("server received photo %q, want %q", got, photo)
+	}
+}
+
+func TestPhotoStrategyNoPhoto(t *testing.T) {
+	s, mock := newStubService(t, config.PhotoStrategyConfig{})
+
+	res, err := s.Run()
+	if !errors.Is(err, ErrNoPhoto) || res.ErrorClass != ErrorClassNoPhoto || res.Stage != StageUpload {
+		t.Fatalf("Run error = %v, ErrorClass = %q, Stage = %q", err, res.ErrorClass, res.Stage)
+	}
+	if _, ok := mock.Signed(wisestutest.PhotoTask.ID); ok {
+		t.Error("task was signed without a photo")
+	}
+}
+
+func TestValidateFlows(t *testing.T) {
+	custom := StrategyFunc(func(sub *Submission) error { return nil })
+	tests := []struct {
+		name    string
+		flows   map[string]string
+		def     string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "builtin", flows: map[string]string{"实习": StrategyLocation, "晚归": StrategySkip}, def: StrategyAuto},
+		{name: "custom", flows: map[string]string{"人脸": "face"}, def: "face", opts: []Option{WithStrategy("face", custom)}},
+		{name: "unregistered", flows: map[string]string{"人脸": "face"}, wantErr: true},
+		{name: "unregistered default", def: "face", wantErr: true},
+		// auto 只能作为 default_flow
+		{name: "auto in flows", flows: map[string]string{"实习": StrategyAuto}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.SignIn.Flows, cfg.SignIn.DefaultFlow = tt.flows, tt.def
+			opts := append([]Option{WithAPIClient(&fakeAPI{}), WithSolver(fakeSolver{}), WithLogger(zap.NewNop())}, tt.opts...)
+			err := NewService(cfg, nil, opts...).ValidateFlows()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFlows error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 通过 WithStrategy 注册的签到方式可以直接在 signin.flows 中使用
+func TestCustomStrategy(t *testing.T) {
+	var submitted []int
+	face := StrategyFunc(func(sub *Submission) error {
+		submitted = append(submitted, sub.Task.ID)
+		return nil
+	})
+	cfg := testConfig()
+	cfg.SignIn.Flows = map[string]string{"人脸": "face"}
+	api := &fakeAPI{tasks: []wisestu.SigninTask{{ID: 7, SigninTypeName: "人脸", BatchNo: 1}}}
+	s := NewService(cfg, nil,
+		WithAPIClient(api),
+		WithSolver(fakeSolver{}),
+		WithClock(&fakeClock{now: testNow}),
+		WithSessionStore(&memStore{token: validToken}),
+		WithLogger(zap.NewNop()),
+		WithStrategy("face", face),
+	)
+
+	res, err := s.Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Strategy != "face" || len(submitted) != 1 || submitted[0] != 7 {
+		t.Errorf("Strategy = %q, submitted %v", res.Strategy, submitted)
+	}
+}
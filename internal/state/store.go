@@ -0,0 +1,163 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store 将运行状态持久化到本地 JSON 文件中，path 为空时仅保存在内存中
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data data
+}
+
+type data struct {
+	LastRuns  map[string]time.Time `json:"last_runs"` // 每个定时计划最近一次成功执行的时间
+	Token     string               `json:"token"`     // 最近一次登录获取的 token
+	Statuses  map[string]bool      `json:"statuses"`  // 每个账号最近一次签到是否成功
+	Paused    map[string]bool      `json:"paused"`    // 已暂停的定时计划
+	Locations map[string]Location  `json:"locations"` // 通过控制台修改的账号签到位置，覆盖配置文件
+}
+
+// Location 是签到使用的经纬度
+type Location struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+}
+
+// Open 打开状态文件，文件不存在时返回空状态
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("读取状态文件失败: %w", err)
+		}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &s.data); err != nil {
+				return nil, fmt.Errorf("解析状态文件失败: %w", err)
+			}
+		}
+	}
+	if s.data.LastRuns == nil {
+		s.data.LastRuns = make(map[string]time.Time)
+	}
+	if s.data.Statuses == nil {
+		s.data.Statuses = make(map[string]bool)
+	}
+	if s.data.Paused == nil {
+		s.data.Paused = make(map[string]bool)
+	}
+	if s.data.Locations == nil {
+		s.data.Locations = make(map[string]Location)
+	}
+	return s, nil
+}
+
+// LastRun 返回定时计划最近一次成功执行的时间，从未执行时返回零值
+func (s *Store) LastRun(schedule string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.LastRuns[schedule]
+}
+
+// SetLastRun 记录定时计划最近一次成功执行的时间
+func (s *Store) SetLastRun(schedule string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.LastRuns[schedule] = t
+	return s.save()
+}
+
+// Token 返回持久化的登录 token
+func (s *Store) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Token
+}
+
+// SetToken 持久化登录 token，以便下次运行时复用会话
+func (s *Store) SetToken(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Token = token
+	return s.save()
+}
+
+// LastStatus 返回账号最近一次签到是否成功，known 为 false 表示没有记录
+func (s *Store) LastStatus(account string) (ok, known bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ok, known = s.data.Statuses[account]
+	return
+}
+
+// SetLastStatus 记录账号最近一次签到是否成功
+func (s *Store) SetLastStatus(account string, ok bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Statuses[account] = ok
+	return s.save()
+}
+
+// Paused 返回定时计划是否已暂停
+func (s *Store) Paused(schedule string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Paused[schedule]
+}
+
+// SetPaused 暂停或恢复定时计划，守护进程重启后仍然生效
+func (s *Store) SetPaused(schedule string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if paused {
+		s.data.Paused[schedule] = true
+	} else {
+		delete(s.data.Paused, schedule)
+	}
+	return s.save()
+}
+
+// Location 返回账号的签到位置覆盖，ok 为 false 表示使用配置文件中的位置
+func (s *Store) Location(account string) (loc Location, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loc, ok = s.data.Locations[account]
+	return
+}
+
+// SetLocation 覆盖账号的签到位置
+func (s *Store) SetLocation(account string, loc Location) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Locations[account] = loc
+	return s.save()
+}
+
+// save 先写入临时文件再重命名，避免进程中断时留下损坏的状态文件
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建状态目录失败: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("写入状态文件失败: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultAPIBase 是 Telegram Bot API 的默认地址
+const DefaultAPIBase = "https://api.telegram.org"
+
+const (
+	// requestTimeout 是普通请求的超时时间
+	requestTimeout = 30 * time.Second
+	// pollMargin 是长轮询请求在轮询超时之外额外等待的时长
+	pollMargin = 15 * time.Second
+)
+
+// Client 是 Telegram Bot API 的简单客户端
+type Client struct {
+	client *resty.Client
+}
+
+// Update 是 getUpdates 返回的单条更新
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// Message 是一条聊天消息
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Text      string `json:"text"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// apiResponse 是 Bot API 的统一响应结构
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+// NewClient 创建一个新的 Client，apiBase 为空时使用官方地址
+func NewClient(apiBase, token string) *Client {
+	if apiBase == "" {
+		apiBase = DefaultAPIBase
+	}
+	// 超时时间按请求设置，长轮询请求需要等待的时长由 poll_timeout 决定
+	client := resty.New().
+		SetBaseURL(strings.TrimRight(apiBase, "/") + "/bot" + token)
+	return &Client{client: client}
+}
+
+// SendMessage 向指定聊天发送纯文本消息
+func (c *Client) SendMessage(chatID int64, text string) error {
+	return c.call("sendMessage", map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil, requestTimeout)
+}
+
+// GetUpdates 以长轮询方式获取 offset 之后的更新，请求超时时间为 timeout 加上一段余量
+func (c *Client) GetUpdates(offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call("getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates, timeout+pollMargin)
+	return updates, err
+}
+
+func (c *Client) call(method string, body, result interface{}, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var apiResp apiResponse
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Post("/" + method)
+	if err != nil {
+		// url.Error 中的地址带有 bot token，只保留底层错误，避免 token 写入日志
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("Telegram %s 请求失败: %w", method, err)
+	}
+	if err := json.Unmarshal(resp.Body(), &apiResp); err != nil {
+		return fmt.Errorf("解析 Telegram %s 响应失败 (%s): %w", method, resp.Status(), err)
+	}
+	if !apiResp.OK {
+		return fmt.Errorf("Telegram %s 返回错误: %s", method, apiResp.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(apiResp.Result, result)
+	}
+	return nil
+}
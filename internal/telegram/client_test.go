@@ -0,0 +1,23 @@
+package telegram
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 请求失败时错误信息中不应包含 bot token
+func TestCallErrorHidesToken(t *testing.T) {
+	const token = "123456:bot-secret"
+	srv := httptest.NewServer(nil)
+	base := srv.URL
+	srv.Close()
+
+	err := NewClient(base, token).SendMessage(1, "hi")
+	if err == nil {
+		t.Fatal("SendMessage succeeded against a closed server")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error contains the token: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+'use strict';
+
+// 控制台通过 /api/v1 控制接口读取和修改数据，访问令牌保存在浏览器本地
+const TOKEN_KEY = 'zhxg-signin-token';
+
+const $ = (sel, root = document) => root.querySelector(sel);
+
+// 当前 token 是否为管理员 token，普通用户不显示修改位置和暂停计划的按钮
+let isAdmin = false;
+
+function token() {
+  return localStorage.getItem(TOKEN_KEY) || '';
+}
+
+async function api(method, path, body) {
+  const opts = { method, headers: { Authorization: 'Bearer ' + token() } };
+  if (body !== undefined) {
+    opts.headers['Content-Type'] = 'application/json';
+    opts.body = JSON.stringify(body);
+  }
+  const resp = await fetch('/api/v1' + path, opts);
+  if (resp.status === 401) {
+    showLogin('访问令牌无效，请重新登录');
+    throw new Error('未授权');
+  }
+  const data = await resp.json();
+  if (!resp.ok) {
+    throw new Error(data.error || resp.statusText);
+  }
+  return data;
+}
+
+function formatTime(s) {
+  if (!s || s.startsWith('0001-')) {
+    return '-';
+  }
+  const d = new Date(s);
+  const pad = (n) => String(n).padStart(2, '0');
+  return `${d.getFullYear()}-${pad(d.getMonth() + 1)}-${pad(d.getDate())} ${pad(d.getHours())}:${pad(d.getMinutes())}:${pad(d.getSeconds())}`;
+}
+
+function cell(row, text, className) {
+  const td = document.createElement('td');
+  td.textContent = text;
+  if (className) {
+    td.className = className;
+  }
+  row.appendChild(td);
+  return td;
+}
+
+function emptyRow(tbody, cols, text) {
+  const tr = document.createElement('tr');
+  const td = cell(tr, text, 'muted');
+  td.colSpan = cols;
+  tbody.appendChild(tr);
+}
+
+function showMessage(text, isError) {
+  const el = $('#message');
+  el.textContent = text;
+  el.className = isError ? 'error' : '';
+  el.hidden = false;
+}
+
+function showLogin(text) {
+  $('#dashboard').hidden = true;
+  $('#logout').hidden = true;
+  $('#login').hidden = false;
+  if (text) {
+    $('#login p').textContent = text;
+  }
+}
+
+// 包装按钮操作，执行期间禁用按钮并显示结果
+async function withButton(button, fn) {
+  button.disabled = true;
+  try {
+    await fn();
+  } catch (err) {
+    showMessage(err.message, true);
+  } finally {
+    button.disabled = false;
+  }
+}
+
+function renderAccount(acc) {
+  const card = $('#account-card').content.cloneNode(true).firstElementChild;
+  $('.account', card).textContent = acc.name ? `${acc.account} (${acc.name})` : acc.account;
+
+  const status = $('.status', card);
+  status.textContent = { success: '成功', failure: '失败', unknown: '暂无记录' }[acc.last_status];
+  status.classList.add(acc.last_status);
+
+  const run = acc.last_run;
+  if (run) {
+    let text = `${formatTime(run.started_at)}（${run.schedule}）`;
+    if (!run.success) {
+      text += ` ${run.error_class}：${run.error}`;
+    }
+    $('.last-run', card).textContent = text;
+    $('.task', card).textContent = run.task_type ? `${run.task_type}（ID ${run.signin_id}，批次 ${run.batch_no}）` : '-';
+  } else {
+    $('.last-run', card).textContent = '-';
+    $('.task', card).textContent = '-';
+  }
+
+  const loc = acc.location;
+  $('.location', card).textContent = `${loc.longitude}, ${loc.latitude}` + (loc.overridden ? '（已在控制台修改）' : '');
+
+  const account = encodeURIComponent(acc.account);
+
+  $('.run', card).addEventListener('click', (e) => withButton(e.target, async () => {
+    showMessage(`正在为 ${acc.account} 签到，请稍候…`);
+    const rec = await api('POST', `/accounts/${account}/run`);
+    if (rec.success) {
+      showMessage(`${acc.account} 签到成功`);
+    } else {
+      showMessage(`${acc.account} 签到失败（${rec.error_class}）：${rec.error}`, true);
+    }
+    await refresh();
+  }));
+
+  $('.tasks', card).addEventListener('click', (e) => withButton(e.target, async () => {
+    const tasks = await api('GET', `/accounts/${account}/tasks`);
+    const list = $('.task-list', card);
+    list.replaceChildren();
+    if (tasks.length === 0) {
+      const li = document.createElement('li');
+      li.textContent = '没有需要签到的任务';
+      list.appendChild(li);
+    }
+    for (const t of tasks) {
+      const li = document.createElement('li');
+      li.textContent = `${t.signin_type_name}（ID ${t.id}，批次 ${t.batch_no}）`;
+      list.appendChild(li);
+    }
+    list.hidden = false;
+  }));
+
+  const form = $('.location-form', card);
+  if (!isAdmin) {
+    $('.edit-location', card).remove();
+    form.remove();
+    return card;
+  }
+  $('.edit-location', card).addEventListener('click', () => {
+    form.longitude.value = loc.longitude;
+    form.latitude.value = loc.latitude;
+    form.hidden = false;
+  });
+  $('.cancel', form).addEventListener('click', () => {
+    form.hidden = true;
+  });
+  form.addEventListener('submit', (e) => {
+    e.preventDefault();
+    withButton($('button[type=submit]', form), async () => {
+      await api('PUT', `/accounts/${account}/location`, {
+        longitude: parseFloat(form.longitude.value),
+        latitude: parseFloat(form.latitude.value),
+      });
+      showMessage(`已修改 ${acc.account} 的签到位置`);
+      await refresh();
+    });
+  });
+
+  return card;
+}
+
+function renderSchedules(schedules) {
+  const tbody = $('#schedules');
+  tbody.replaceChildren();
+  if (schedules.length === 0) {
+    emptyRow(tbody, 6, '没有定时计划（守护进程可能以轮询模式运行）');
+    return;
+  }
+  for (const s of schedules) {
+    const tr = document.createElement('tr');
+    cell(tr, s.name);
+    cell(tr, s.cron);
+    cell(tr, s.window);
+    cell(tr, formatTime(s.last_run));
+    const badge = document.createElement('span');
+    badge.className = 'badge ' + (s.paused ? 'paused' : 'success');
+    badge.textContent = s.paused ? '已暂停' : '运行中';
+    cell(tr, '').appendChild(badge);
+
+    if (!isAdmin) {
+      cell(tr, '');
+      tbody.appendChild(tr);
+      continue;
+    }
+    const button = document.createElement('button');
+    button.className = 'secondary';
+    button.textContent = s.paused ? '恢复' : '暂停';
+    button.addEventListener('click', () => withButton(button, async () => {
+      await api('POST', `/schedules/${encodeURIComponent(s.name)}/${s.paused ? 'resume' : 'pause'}`);
+      await refresh();
+    }));
+    cell(tr, '').appendChild(button);
+    tbody.appendChild(tr);
+  }
+}
+
+function renderUpcoming(runs) {
+  const tbody = $('#upcoming');
+  tbody.replaceChildren();
+  if (runs.length === 0) {
+    emptyRow(tbody, 4, '没有即将执行的定时签到');
+    return;
+  }
+  for (const r of runs) {
+    const tr = document.createElement('tr');
+    cell(tr, formatTime(r.fire));
+    cell(tr, formatTime(r.latest));
+    cell(tr, r.schedule);
+    let note = '';
+    if (r.paused) {
+      note = '计划已暂停';
+    } else if (r.skipped) {
+      note = '跳过：' + r.skip_reason;
+    }
+    cell(tr, note, 'muted');
+    tbody.appendChild(tr);
+  }
+}
+
+function renderFailures(records) {
+  const tbody = $('#failures');
+  tbody.replaceChildren();
+  if (records.length === 0) {
+    emptyRow(tbody, 6, '最近没有失败记录');
+    return;
+  }
+  for (const r of records) {
+    const tr = document.createElement('tr');
+    cell(tr, formatTime(r.started_at));
+    cell(tr, r.name ? `${r.account} (${r.name})` : r.account);
+    cell(tr, r.schedule);
+    cell(tr, r.stage);
+    cell(tr, r.error_class);
+    cell(tr, r.error, 'wrap');
+    tbody.appendChild(tr);
+  }
+}
+
+async function refresh() {
+  const [me, accounts, schedules, upcoming, failures] = await Promise.all([
+    api('GET', '/me'),
+    api('GET', '/accounts'),
+    api('GET', '/schedules'),
+    api('GET', '/upcoming?n=3'),
+    api('GET', '/history?status=failure&limit=10'),
+  ]);
+  isAdmin = me.admin;
+
+  $('#accounts').replaceChildren(...accounts.map(renderAccount));
+  renderSchedules(schedules);
+  renderUpcoming(upcoming);
+  renderFailures(failures.records);
+
+  $('#login').hidden = true;
+  $('#dashboard').hidden = false;
+  $('#logout').hidden = false;
+}
+
+$('#login-form').addEventListener('submit', (e) => {
+  e.preventDefault();
+  localStorage.setItem(TOKEN_KEY, $('#token').value);
+  refresh().catch((err) => showLogin(err.message));
+});
+
+$('#logout').addEventListener('click', () => {
+  localStorage.removeItem(TOKEN_KEY);
+  showLogin();
+});
+
+if (token()) {
+  refresh().catch((err) => showLogin(err.message));
+} else {
+  showLogin();
+}
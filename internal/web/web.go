@@ -0,0 +1,21 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// static 包含控制台的全部前端文件，不依赖任何外部 CDN，可在内网使用
+//
+//go:embed static
+var static embed.FS
+
+// Handler 返回控制台页面的处理器，页面通过 /api/v1 控制接口读取和修改数据
+func Handler() http.Handler {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // static 目录在编译时嵌入，不会出错
+	}
+	return http.FileServer(http.FS(sub))
+}
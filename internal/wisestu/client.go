@@ -0,0 +1,268 @@
+// Package wisestu 是智慧学工接口的客户端，每个 action 对应一个类型化的方法，统一解析响应并映射错误。
+package wisestu
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"zhxg-signin/internal/client"
+)
+
+// 接口路径
+const (
+	loginPath   = "/dnui/api/user/loginout.api"
+	stuInfoPath = "/dnui/api/student/basic/stuInfo.api"
+	signinPath  = "/dnui/api/student/signin/signin.api"
+)
+
+// 学校服务器返回的响应码
+const (
+	CodeOK            = 0
+	CodeNeedLogin     = 401
+	CodeWrongPassword = 1002
+	CodeWrongCaptcha  = 1005
+)
+
+// 登录时模拟的客户端信息，从 Apifox CLI 中获取
+const (
+	clientType  = "App"
+	clientVer   = "2.0.1"
+	clientExtra = `{"available":true,"platform":"Android","version":"15","uuid":"","cordova":"8.1.0","model":"22081212C","manufacturer":"Xiaomi","isVirtual":false,"serial":"unknown"}`
+)
+
+// ErrNoToken 表示登录返回成功，但响应头中没有 token
+var ErrNoToken = errors.New("登录成功但未在响应头中找到 token")
+
+// APIError 表示接口返回了非 0 的响应码
+type APIError struct {
+	Action  string
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("code: %d, message: %s", e.Code, e.Message)
+}
+
+// CodeOf 返回 err 中的接口响应码，err 不是 APIError 时 ok 为 false
+func CodeOf(err error) (code int, ok bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code, true
+	}
+	return 0, false
+}
+
+// envelope 是接口响应的通用结构
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// Client 是智慧学工接口的客户端
+type Client struct {
+	http *client.HTTPClient
+}
+
+// New 使用 hc 创建客户端，token 和请求指标由 hc 负责
+func New(hc *client.HTTPClient) *Client {
+	return &Client{http: hc}
+}
+
+// SetAuthToken 设置之后的请求携带的 token，为空时不携带
+func (c *Client) SetAuthToken(token string) {
+	c.http.SetAuthToken(token)
+}
+
+// WrapTransport 用 wrap 包装底层的 HTTP transport，用于录制和回放请求
+func (c *Client) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.http.WrapTransport(wrap)
+}
+
+// call 发送请求并解析通用响应，响应码不为 0 时返回 *APIError，result 不为 nil 时解析响应中的 result 字段
+func (c *Client) call(path, action string, body, result interface{}) (*client.Response, error) {
+	resp, err := c.http.Post(path, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("%s 请求失败: %d %s", action, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var env envelope
+	if err := json.Unmarshal(resp.Body, &env); err != nil {
+		return nil, fmt.Errorf("解析 %s 响应失败: %w", action, err)
+	}
+	if env.Code != CodeOK {
+		return nil, &APIError{Action: action, Code: env.Code, Message: env.Message}
+	}
+	if result != nil && len(env.Result) > 0 {
+		if err := json.Unmarshal(env.Result, result); err != nil {
+			return nil, fmt.Errorf("解析 %s 响应失败: %w", action, err)
+		}
+	}
+	return resp, nil
+}
+
+// QueryVerificationQuestion 获取登录验证码
+func (c *Client) QueryVerificationQuestion() (*Verification, error) {
+	resp, err := c.call(loginPath, "queryVerificationQuestion",
+		map[string]string{"action": "queryVerificationQuestion", "client_type": clientType}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var v Verification
+	if err := json.Unmarshal(resp.Body, &v); err != nil {
+		return nil, fmt.Errorf("解析 queryVerificationQuestion 响应失败: %w", err)
+	}
+	return &v, nil
+}
+
+// LoginStudent 使用验证码答案登录并返回响应头中的 token，响应成功但没有 token 时返回 ErrNoToken
+func (c *Client) LoginStudent(loginName, password string, v *Verification, answer string) (string, error) {
+	resp, err := c.call(loginPath, "loginStudent", LoginRequest{
+		Action:             "loginStudent",
+		VerificationID:     v.VerificationID,
+		VerificationImage:  v.VerificationImage,
+		VerificationAnswer: answer,
+		LoginName:          loginName,
+		Password:           password,
+		ClientType:         clientType,
+		ClientVer:          clientVer,
+		ClientExtra:        clientExtra,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	token := resp.Header.Get("token")
+	if token == "" {
+		return "", ErrNoToken
+	}
+	return token, nil
+}
+
+// QueryMyStuInfo 获取当前登录学生的信息，未登录时返回 CodeNeedLogin
+func (c *Client) QueryMyStuInfo() (*Student, error) {
+	var s Student
+	if _, err := c.call(stuInfoPath, "queryMyStuInfo", map[string]string{"action": "queryMyStuInfo"}, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetUnSigninList 获取未签到的任务列表
+func (c *Client) GetUnSigninList(pageNum, pageSize int) ([]SigninTask, error) {
+	var list UnSigninList
+	if _, err := c.call(signinPath, "getUnSigninList", UnSigninListRequest{
+		Action:   "getUnSigninList",
+		PageSize: pageSize,
+		PageNum:  pageNum,
+	}, &list); err != nil {
+		return nil, err
+	}
+	return list.List, nil
+}
+
+// GetSigninDetails 进入签到并返回签到详情
+func (c *Client) GetSigninDetails(id, batchNo int) (*SigninDetails, error) {
+	var d SigninDetails
+	resp, err := c.call(signinPath, "getSigninDetails", SigninRequest{
+		Action:  "getSigninDetails",
+		ID:      id,
+		BatchNo: batchNo,
+	}, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保留原始的 result，签到详情的字段名尚未确认
+	var env envelope
+	if err := json.Unmarshal(resp.Body, &env); err == nil {
+		d.Raw = env.Result
+	}
+	return &d, nil
+}
+
+// CheckOutsideFlag 检查坐标是否在签到范围外
+func (c *Client) CheckOutsideFlag(id int, lng, lat float64) (outside bool, err error) {
+	var f OutsideFlag
+	if _, err := c.call(signinPath, "checkOutsideFlag", CheckOutsideFlagRequest{
+		Action: "checkOutsideFlag",
+		ID:     id,
+		Lng:    lng,
+		Lat:    lat,
+	}, &f); err != nil {
+		return false, err
+	}
+	return f.OutsideFlag == "1", nil
+}
+
+// UpdateLocationSignin 提交位置签到
+func (c *Client) UpdateLocationSignin(id, batchNo int, loc SigninLocation, outsideFlag string) error {
+	raw, err := json.Marshal(loc)
+	if err != nil {
+		return fmt.Errorf("序列化 signin_location 失败: %w", err)
+	}
+
+	_, err = c.call(signinPath, "updateLocationSignin", UpdateLocationSigninRequest{
+		Action:         "updateLocationSignin",
+		ID:             id,
+		BatchNo:        batchNo,
+		SigninLocation: string(raw),
+		OutsideFlag:    outsideFlag,
+	}, nil)
+	return err
+}
+
+// UploadSigninImage 上传签到照片并返回照片地址。
+// 实验性：action 名称、请求字段和响应格式都是推测的，尚未在学校服务器的真实请求中见到
+func (c *Client) UploadSigninImage(id, batchNo int, fileName string, data []byte) (string, error) {
+	var img UploadedImage
+	if _, err := c.call(signinPath, "uploadSigninImage", UploadSigninImageRequest{
+		Action:   "uploadSigninImage",
+		ID:       id,
+		BatchNo:  batchNo,
+		FileName: fileName,
+		Image:    base64.StdEncoding.EncodeToString(data),
+	}, &img); err != nil {
+		return "", err
+	}
+	if img.URL == "" {
+		return "", fmt.Errorf("uploadSigninImage 未返回照片地址")
+	}
+	return img.URL, nil
+}
+
+// UpdatePhotoSignin 提交拍照签到，photoURL 为 UploadSigninImage 返回的地址。
+// 实验性：与 UploadSigninImage 一样是推测的，尚未经过验证
+func (c *Client) UpdatePhotoSignin(id, batchNo int, photoURL string, loc SigninLocation) error {
+	raw, err := json.Marshal(loc)
+	if err != nil {
+		return fmt.Errorf("序列化 signin_location 失败: %w", err)
+	}
+
+	_, err = c.call(signinPath, "updatePhotoSignin", UpdatePhotoSigninRequest{
+		Action:         "updatePhotoSignin",
+		ID:             id,
+		BatchNo:        batchNo,
+		PhotoURL:       photoURL,
+		SigninLocation: string(raw),
+	}, nil)
+	return err
+}
+
+// GetSigninSuccess 获取签到情况，确认签到已被记录
+func (c *Client) GetSigninSuccess(id, batchNo int) error {
+	_, err := c.call(signinPath, "getSigninSuccess", SigninRequest{
+		Action:  "getSigninSuccess",
+		ID:      id,
+		BatchNo: batchNo,
+	}, nil)
+	return err
+}
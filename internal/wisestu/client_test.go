@@ -0,0 +1,105 @@
+package wisestu_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"zhxg-signin/internal/client"
+	"zhxg-signin/internal/wisestu"
+	"zhxg-signin/internal/wisestutest"
+)
+
+// login 在模拟服务上登录，返回已携带 token 的客户端
+func login(t *testing.T, opts wisestutest.Options) (*wisestu.Client, *wisestutest.Server) {
+	t.Helper()
+	mock, srv := wisestutest.NewServer(opts)
+	t.Cleanup(srv.Close)
+
+	api := wisestu.New(client.NewHTTPClient(srv.URL, false))
+	v, err := api.QueryVerificationQuestion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, _ := mock.Answer(v.VerificationID)
+	token, err := api.LoginStudent("20230001", "password", v, strconv.Itoa(answer))
+	if err != nil {
+		t.Fatalf("LoginStudent: %v", err)
+	}
+	api.SetAuthToken(token)
+	return api, mock
+}
+
+func TestGetSigninDetailsKeepsRaw(t *testing.T) {
+	task := wisestutest.DefaultTask
+	fence := wisestutest.DefaultFence
+	api, _ := login(t, wisestutest.Options{Tasks: []wisestu.SigninTask{task}, Fence: &fence, PhotoRequired: true})
+
+	d, err := api.GetSigninDetails(task.ID, task.BatchNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.NeedPhoto || d.Radius != fence.Radius {
+		t.Errorf("NeedPhoto = %v, Radius = %v", d.NeedPhoto, d.Radius)
+	}
+
+	// 原始响应中的字段应与解析结果一致，字段名不符时可据此修正
+	var raw map[string]interface{}
+	if err := json.Unmarshal(d.Raw, &raw); err != nil {
+		t.Fatalf("Raw is not JSON: %v\n%s", err, d.Raw)
+	}
+	if raw["signin_type_name"] != task.SigninTypeName || raw["need_photo"] != "1" {
+		t.Errorf("Raw = %s", d.Raw)
+	}
+}
+
+// 拍照签到的接口是推测的，这里只验证客户端与模拟服务中的推测一致
+func TestPhotoSignin(t *testing.T) {
+	opts, err := wisestutest.Scenario(wisestutest.ScenarioPhoto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, mock := login(t, opts)
+	task := wisestutest.PhotoTask
+	loc := wisestu.SigninLocation{Point: wisestu.SigninLocationPoint{Lng: 109.4, Lat: 24.3}}
+
+	if _, err := api.GetSigninDetails(task.ID, task.BatchNo); err != nil {
+		t.Fatal(err)
+	}
+	err = api.UpdateLocationSignin(task.ID, task.BatchNo, loc, "1")
+	if code, ok := wisestu.CodeOf(err); !ok || code != wisestutest.CodePhotoRequired {
+		t.Errorf("UpdateLocationSignin error = %v, want code %d", err, wisestutest.CodePhotoRequired)
+	}
+
+	photo := []byte("\xff\xd8\xff\xe0 fake jpeg")
+	url, err := api.UploadSigninImage(task.ID, task.BatchNo, "1.jpg", photo)
+	if err != nil {
+		t.Fatalf("UploadSigninImage: %v", err)
+	}
+	if err := api.UpdatePhotoSignin(task.ID, task.BatchNo, url, loc); err != nil {
+		t.Fatalf("UpdatePhotoSignin: %v", err)
+	}
+	if err := api.GetSigninSuccess(task.ID, task.BatchNo); err != nil {
+		t.Fatalf("GetSigninSuccess: %v", err)
+	}
+	if got, ok := mock.Photo(task.ID); !ok || string(got) != string(photo) {
+		t.Errorf("server received photo %q, want %q", got, photo)
+	}
+}
+
+func TestUpdatePhotoSigninWithoutUpload(t *testing.T) {
+	opts, err := wisestutest.Scenario(wisestutest.ScenarioPhoto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, _ := login(t, opts)
+	task := wisestutest.PhotoTask
+
+	if _, err := api.GetSigninDetails(task.ID, task.BatchNo); err != nil {
+		t.Fatal(err)
+	}
+	err = api.UpdatePhotoSignin(task.ID, task.BatchNo, "/not/uploaded.jpg", wisestu.SigninLocation{})
+	if _, ok := wisestu.CodeOf(err); !ok {
+		t.Errorf("UpdatePhotoSignin error = %v, want an APIError", err)
+	}
+}
@@ -0,0 +1,182 @@
+package wisestu
+
+import "encoding/json"
+
+// Verification 是 queryVerificationQuestion 返回的验证码，字段直接位于响应顶层
+type Verification struct {
+	VerificationID    string `json:"verification_id"`
+	VerificationImage string `json:"verification_image"`
+}
+
+// LoginRequest 登录请求的结构
+type LoginRequest struct {
+	Action             string `json:"action"`
+	VerificationID     string `json:"verification_id"`
+	VerificationImage  string `json:"verification_image"`
+	VerificationAnswer string `json:"verification_answer"`
+	LoginName          string `json:"login_name"`
+	Password           string `json:"password"`
+	ClientType         string `json:"client_type"`
+	ClientVer          string `json:"client_ver"`
+	ClientExtra        string `json:"client_extra"`
+}
+
+// Student 是 queryMyStuInfo 返回的学生信息
+type Student struct {
+	RealName       string `json:"real_name"`
+	StuNo          string `json:"stu_no"`
+	Gender         string `json:"gender,omitempty"`
+	College        string `json:"college"`
+	Major          string `json:"major,omitempty"`
+	ClassName      string `json:"class_name"`
+	Grade          string `json:"grade,omitempty"`
+	Counselor      string `json:"counselor_name,omitempty"`
+	CounselorPhone string `json:"counselor_phone,omitempty"`
+
+	// Extra 保存接口返回但未建模的字段，便于 whoami 完整展示
+	Extra map[string]interface{} `json:"-"`
+}
+
+// UnmarshalJSON 解析已建模的字段，并把其余字段保存到 Extra
+func (s *Student) UnmarshalJSON(data []byte) error {
+	type plain Student
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, key := range []string{"real_name", "stu_no", "gender", "college", "major", "class_name", "grade", "counselor_name", "counselor_phone"} {
+		delete(all, key)
+	}
+	s.Extra = nil
+	if len(all) > 0 {
+		s.Extra = all
+	}
+	return nil
+}
+
+// UnSigninListRequest 未签到列表请求的结构
+type UnSigninListRequest struct {
+	Action   string `json:"action"`
+	PageSize int    `json:"pageSize"`
+	PageNum  int    `json:"pageNum"`
+}
+
+// UnSigninList 是 getUnSigninList 返回的 result
+type UnSigninList struct {
+	List []SigninTask `json:"list"`
+}
+
+// SigninTask 未签到列表中的单个签到任务
+type SigninTask struct {
+	ID             int    `json:"id"`
+	SigninTypeName string `json:"signin_type_name"`
+	BatchNo        int    `json:"batch_no"`
+}
+
+// SigninRequest 是只需要任务 ID 和批次号的签到接口请求，如进入签到和签到情况
+type SigninRequest struct {
+	Action  string `json:"action"`
+	ID      int    `json:"id"`
+	BatchNo int    `json:"batch_no"`
+}
+
+// SigninDetails 是 getSigninDetails 返回的签到详情，包括签到时间、签到范围和是否需要拍照。
+//
+// 除 id、batch_no 和 signin_type_name 外，其余字段的 JSON 键名是推测的，尚未在学校服务器的
+// 真实响应中确认。键名不符时这些字段保持零值：时间和范围检查会直接通过，auto 也不会选择拍照签到。
+// 原始响应保存在 Raw 中并以 debug 级别记录，便于对照修正
+type SigninDetails struct {
+	ID             int     `json:"id"`
+	BatchNo        int     `json:"batch_no"`
+	SigninTypeName string  `json:"signin_type_name"`
+	StartTime      Time    `json:"start_time"` // 未确认的键名，零值表示不限制
+	EndTime        Time    `json:"end_time"`   // 未确认的键名，零值表示不限制
+	Lng            float64 `json:"lng"`        // 未确认的键名，签到范围的中心
+	Lat            float64 `json:"lat"`        // 未确认的键名
+	Radius         float64 `json:"radius"`     // 未确认的键名，签到范围的半径，单位为米，0 表示不限制
+	Address        string  `json:"address,omitempty"`
+	NeedPhoto      Flag    `json:"need_photo"` // 未确认的键名
+
+	Raw json.RawMessage `json:"-"` // 响应中原始的 result
+}
+
+// HasFence 返回签到是否限制了位置范围
+func (d *SigninDetails) HasFence() bool {
+	return d.Radius > 0 && (d.Lng != 0 || d.Lat != 0)
+}
+
+// DistanceTo 返回坐标与签到范围中心的距离，单位为米
+func (d *SigninDetails) DistanceTo(lng, lat float64) float64 {
+	return Distance(d.Lng, d.Lat, lng, lat)
+}
+
+// CheckOutsideFlagRequest 点击签到请求的结构
+type CheckOutsideFlagRequest struct {
+	Action string  `json:"action"`
+	ID     int     `json:"id"`
+	Lng    float64 `json:"lng"`
+	Lat    float64 `json:"lat"`
+}
+
+// OutsideFlag 是 checkOutsideFlag 返回的 result
+type OutsideFlag struct {
+	OutsideFlag string `json:"outside_flag"` // "1" 表示不在签到范围内
+}
+
+// UpdateLocationSigninRequest 更新位置签到请求的结构
+type UpdateLocationSigninRequest struct {
+	Action         string `json:"action"`
+	ID             int    `json:"id"`
+	BatchNo        int    `json:"batch_no"`
+	SigninLocation string `json:"signin_location"` // 这是一个JSON字符串
+	OutsideFlag    string `json:"outside_flag"`
+}
+
+// UploadSigninImageRequest 上传签到照片请求的结构，字段名是推测的，尚未经过验证
+type UploadSigninImageRequest struct {
+	Action   string `json:"action"`
+	ID       int    `json:"id"`
+	BatchNo  int    `json:"batch_no"`
+	FileName string `json:"file_name"`
+	Image    string `json:"image"` // base64 编码的图片
+}
+
+// UploadedImage 是 uploadSigninImage 返回的 result，字段名是推测的
+type UploadedImage struct {
+	URL string `json:"url"`
+}
+
+// UpdatePhotoSigninRequest 提交拍照签到请求的结构，字段名是推测的，尚未经过验证
+type UpdatePhotoSigninRequest struct {
+	Action         string `json:"action"`
+	ID             int    `json:"id"`
+	BatchNo        int    `json:"batch_no"`
+	PhotoURL       string `json:"photo_url"`
+	SigninLocation string `json:"signin_location"` // 这是一个JSON字符串
+}
+
+// SigninLocation 用于 UpdateLocationSigninRequest 中的 signin_location 字段
+type SigninLocation struct {
+	Point             SigninLocationPoint             `json:"point"`
+	Address           string                          `json:"address"`
+	AddressComponents SigninLocationAddressComponents `json:"addressComponents"`
+}
+
+// SigninLocationPoint 用于 SigninLocation 中的 point 字段
+type SigninLocationPoint struct {
+	Lng float64 `json:"lng"`
+	Lat float64 `json:"lat"`
+}
+
+// SigninLocationAddressComponents 用于 SigninLocation 中的 addressComponents 字段
+type SigninLocationAddressComponents struct {
+	StreetNumber string `json:"streetNumber"`
+	Street       string `json:"street"`
+	District     string `json:"district"`
+	City         string `json:"city"`
+	Province     string `json:"province"`
+}
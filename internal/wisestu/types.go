@@ -0,0 +1,81 @@
+package wisestu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serverLocation 是学校服务器返回的时间所在的时区
+var serverLocation = time.FixedZone("CST", 8*3600)
+
+// timeLayouts 是接口中出现过的时间格式
+var timeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05"}
+
+// Time 是接口返回的北京时间，支持字符串和毫秒时间戳，空字符串和 null 解析为零值
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON 解析 "2006-01-02 15:04:05" 格式的字符串或毫秒时间戳
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	var ms int64
+	if json.Unmarshal(data, &ms) == nil {
+		t.Time = time.UnixMilli(ms).In(serverLocation)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("无效的时间 %s", data)
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, s, serverLocation); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("无效的时间 %q", s)
+}
+
+// MarshalJSON 以接口使用的格式输出时间，零值输出空字符串
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(t.In(serverLocation).Format(timeLayouts[0]))
+}
+
+// Flag 是接口中表示是否的字段，兼容 "1"、1 和 true
+type Flag bool
+
+// UnmarshalJSON 将 "1"、1、"true" 和 true 解析为 true，其余为 false
+func (f *Flag) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	v, err := strconv.ParseBool(s)
+	*f = Flag(err == nil && v)
+	return nil
+}
+
+// Distance 返回两个坐标之间的球面距离，单位为米
+func Distance(lng1, lat1, lng2, lat2 float64) float64 {
+	const earthRadius = 6371000
+	rad := math.Pi / 180
+	dLat, dLng := (lat2-lat1)*rad, (lng2-lng1)*rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
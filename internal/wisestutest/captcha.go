@@ -0,0 +1,76 @@
+package wisestutest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+)
+
+// glyphs 是绘制验证码用的 5x7 点阵字体
+var glyphs = map[rune][7]string{
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"####.", "....#", "....#", ".###.", "....#", "....#", "####."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'+': {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'=': {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+}
+
+const glyphScale = 4
+
+// newExpression 随机生成一道两位数以内的加减法算式，返回算式文本和答案
+func newExpression(rng *rand.Rand) (string, int) {
+	a, b := rng.Intn(20)+1, rng.Intn(10)+1
+	if rng.Intn(2) == 0 {
+		return fmt.Sprintf("%d + %d =", a, b), a + b
+	}
+	if a < b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d - %d =", a, b), a - b
+}
+
+// renderExpression 将算式绘制为 PNG 图片并返回其 base64 编码
+func renderExpression(expr string) string {
+	const (
+		pad   = 2
+		width = 6 // 字符宽度加一列间距
+	)
+	img := image.NewGray(image.Rect(0, 0, (len(expr)*width+pad*2)*glyphScale, (7+pad*2)*glyphScale))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for i, r := range expr {
+		glyph := glyphs[r]
+		for y, row := range glyph {
+			for x, dot := range row {
+				if dot != '#' {
+					continue
+				}
+				px, py := (pad+i*width+x)*glyphScale, (pad+y)*glyphScale
+				for dy := 0; dy < glyphScale; dy++ {
+					for dx := 0; dx < glyphScale; dx++ {
+						img.SetGray(px+dx, py+dy, color.Gray{})
+					}
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,126 @@
+package wisestutest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"zhxg-signin/internal/wisestu"
+)
+
+// 预置场景的名称
+const (
+	ScenarioOK             = "ok"
+	ScenarioWrongCaptcha   = "wrong-captcha"
+	ScenarioWrongPassword  = "wrong-password"
+	ScenarioMissingToken   = "missing-token"
+	ScenarioExpiredSession = "expired-session"
+	ScenarioEmptyList      = "empty-list"
+	ScenarioMultipleTasks  = "multiple-tasks"
+	ScenarioOutsideFence   = "outside-fence"
+	ScenarioNotOpen        = "not-open"
+	ScenarioClosed         = "closed"
+	ScenarioPhoto          = "photo"
+	ScenarioClick          = "click"
+)
+
+// DefaultTask 是预置场景中默认的实习签到任务
+var DefaultTask = wisestu.SigninTask{ID: 1001, SigninTypeName: "实习", BatchNo: 20250801}
+
+// PhotoTask 是 photo 场景中需要拍照的签到任务
+var PhotoTask = wisestu.SigninTask{ID: 1004, SigninTypeName: "拍照", BatchNo: 20250801}
+
+// ClickTask 是 click 场景中的点击签到任务
+var ClickTask = wisestu.SigninTask{ID: 1005, SigninTypeName: "点名", BatchNo: 20250801}
+
+// DefaultFence 是 outside-fence 场景的签到围栏，位于北京天安门，半径 100 米
+var DefaultFence = Fence{Lng: 116.397128, Lat: 39.916527, Radius: 100}
+
+var scenarios = map[string]struct {
+	description string
+	options     func(o *Options)
+}{
+	ScenarioOK: {
+		description: "一个实习签到任务，登录和签到都会成功",
+		options:     func(o *Options) {},
+	},
+	ScenarioWrongCaptcha: {
+		description: "前两次登录返回验证码错误 (1005)，第三次起按答案判断",
+		options:     func(o *Options) { o.CaptchaFailures = 2 },
+	},
+	ScenarioWrongPassword: {
+		description: "验证码正确时返回用户名或密码错误 (1002)",
+		options:     func(o *Options) { o.Password = "not-the-password" },
+	},
+	ScenarioMissingToken: {
+		description: "登录返回成功，但响应头中没有 token",
+		options:     func(o *Options) { o.OmitToken = true },
+	},
+	ScenarioExpiredSession: {
+		description: "登录获得的 token 只能用于一次已登录请求，之后的请求返回 Need Login (401)",
+		options:     func(o *Options) { o.SessionRequests = 1 },
+	},
+	ScenarioEmptyList: {
+		description: "没有未签到的任务",
+		options:     func(o *Options) { o.Tasks = []wisestu.SigninTask{} },
+	},
+	ScenarioMultipleTasks: {
+		description: "三个未签到任务，实习任务不在列表首位",
+		options: func(o *Options) {
+			o.Tasks = []wisestu.SigninTask{
+				{ID: 1002, SigninTypeName: "晚归", BatchNo: 20250801},
+				DefaultTask,
+				{ID: 1003, SigninTypeName: "实习", BatchNo: 20250802},
+			}
+		},
+	},
+	ScenarioOutsideFence: {
+		description: "签到围栏位于北京，提交的坐标不在围栏内时返回不在签到范围内 (推测的响应码 1101)",
+		options: func(o *Options) {
+			fence := DefaultFence
+			o.Fence = &fence
+		},
+	},
+	ScenarioNotOpen: {
+		description: "签到在一小时后开始，提前提交返回不在签到时间内 (推测的响应码 1102)",
+		options:     func(o *Options) { o.Opens = time.Now().Add(time.Hour) },
+	},
+	ScenarioClosed: {
+		description: "签到已在一小时前结束，任务仍留在未签到列表中，提交返回不在签到时间内 (推测的响应码 1102)",
+		options:     func(o *Options) { o.Closes = time.Now().Add(-time.Hour) },
+	},
+	ScenarioPhoto: {
+		description: "一个拍照签到任务，签到详情要求拍照，位置签到返回该签到需要拍照 (推测的响应码 1103)",
+		options: func(o *Options) {
+			o.Tasks = []wisestu.SigninTask{PhotoTask}
+			o.PhotoRequired = true
+		},
+	},
+	ScenarioClick: {
+		description: "一个点名任务和一个晚归任务，进入签到后调用 checkOutsideFlag 即完成点击签到",
+		options: func(o *Options) {
+			o.Tasks = []wisestu.SigninTask{{ID: 1006, SigninTypeName: "晚归", BatchNo: 20250801}, ClickTask}
+		},
+	},
+}
+
+// Scenario 返回预置场景的选项
+func Scenario(name string) (Options, error) {
+	sc, ok := scenarios[name]
+	if !ok {
+		return Options{}, fmt.Errorf("未知的场景: %s", name)
+	}
+	opts := Options{Tasks: []wisestu.SigninTask{DefaultTask}}
+	sc.options(&opts)
+	return opts, nil
+}
+
+// Scenarios 返回所有预置场景的名称和说明，按名称排序
+func Scenarios() [][2]string {
+	list := make([][2]string, 0, len(scenarios))
+	for name, sc := range scenarios {
+		list = append(list, [2]string{name, sc.description})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i][0] < list[j][0] })
+	return list
+}
@@ -0,0 +1,448 @@
+// Package wisestutest 提供一个模拟智慧学工接口的 HTTP 服务，用于在不访问学校服务器的情况下端到端地测试签到流程。
+//
+// 登录、学生信息、任务列表和位置签到的请求格式来自对学校服务器的抓包；签到详情中的时间、
+// 围栏和拍照字段，以及拍照签到的 uploadSigninImage、updatePhotoSignin 接口尚未在真实响应中
+// 见到，模拟服务与 wisestu.Client 采用了同样的推测。针对这些部分的测试只能验证客户端与推测
+// 一致，不能说明与学校服务器兼容
+package wisestutest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"zhxg-signin/internal/wisestu"
+)
+
+// 模拟服务返回的响应码，以下几个在学校服务器的实际响应中出现过
+const (
+	CodeOK            = 0
+	CodeNeedLogin     = 401
+	CodeWrongPassword = 1002
+	CodeWrongCaptcha  = 1005
+)
+
+// 以下响应码是推测的，学校服务器在这些情况下实际返回的响应码尚未确认。
+// 客户端只把它们当作普通的 APIError 处理，不依赖具体的值
+const (
+	CodeBadRequest    = 400
+	CodeOutsideFence  = 1101
+	CodeNotInWindow   = 1102
+	CodePhotoRequired = 1103
+	CodeNotFound      = 1201
+)
+
+// Fence 是签到围栏，提交的坐标与中心的距离超过 Radius 米时拒绝签到
+type Fence struct {
+	Lng    float64
+	Lat    float64
+	Radius float64
+}
+
+// Student 是 stuInfo.api 返回的学生信息
+type Student = wisestu.Student
+
+// Options 描述模拟服务的行为，零值字段使用默认值
+type Options struct {
+	Username string
+	Password string
+	Student  Student
+	Tasks    []wisestu.SigninTask // 初始的未签到任务
+
+	CaptchaFailures int       // 前几次登录无论答案是否正确都返回验证码错误
+	OmitToken       bool      // 登录成功但不在响应头中返回 token
+	SessionRequests int       // 每个 token 可用于多少次已登录请求，0 表示不限制，用于模拟会话中途过期
+	Fence           *Fence    // 为 nil 时不校验签到位置
+	Opens           time.Time // 签到开始时间，零值表示不限制
+	Closes          time.Time // 签到结束时间，零值表示不限制
+	PhotoRequired   bool      // 签到详情要求拍照，位置签到会被拒绝
+	Seed            int64     // 验证码算式的随机种子，0 表示使用当前时间
+}
+
+// Server 是模拟的智慧学工服务，实现了 loginout.api、stuInfo.api 和 signin.api，包括位置签到和拍照签到
+type Server struct {
+	opts Options
+	rng  *rand.Rand
+
+	mu       sync.Mutex
+	captchas map[string]int // 验证码 ID 到答案
+	images   map[string]int // 验证码图片到答案
+	tokens   map[string]int // token 到剩余可用次数，-1 表示不限制
+	tasks    []wisestu.SigninTask
+	entered  map[int]bool // 已调用 getSigninDetails 的任务
+	signed   map[int]wisestu.SigninLocationPoint
+	uploads  map[string][]byte // 照片地址到上传的照片
+	photos   map[int][]byte    // 拍照签到的任务使用的照片
+	logins   int
+	actions  []string
+	seq      int
+}
+
+// New 创建一个模拟服务，可直接作为 http.Handler 使用
+func New(opts Options) *Server {
+	if opts.Username == "" {
+		opts.Username = "20230001"
+	}
+	if opts.Password == "" {
+		opts.Password = "password"
+	}
+	if opts.Student.RealName == "" {
+		opts.Student = Student{
+			RealName:  "张三",
+			StuNo:     opts.Username,
+			Gender:    "男",
+			College:   "计算机学院",
+			Major:     "软件工程",
+			ClassName: "软件工程2301",
+			Grade:     "2023",
+			Counselor: "李老师",
+		}
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &Server{
+		opts:     opts,
+		rng:      rand.New(rand.NewSource(seed)),
+		captchas: make(map[string]int),
+		images:   make(map[string]int),
+		tokens:   make(map[string]int),
+		tasks:    append([]wisestu.SigninTask(nil), opts.Tasks...),
+		entered:  make(map[int]bool),
+		signed:   make(map[int]wisestu.SigninLocationPoint),
+		uploads:  make(map[string][]byte),
+		photos:   make(map[int][]byte),
+	}
+}
+
+// NewServer 创建模拟服务并在本地随机端口启动，使用完毕后需调用 Close
+func NewServer(opts Options) (*Server, *httptest.Server) {
+	s := New(opts)
+	return s, httptest.NewServer(s)
+}
+
+// Actions 返回收到的所有请求的 action，按收到的顺序排列
+func (s *Server) Actions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.actions...)
+}
+
+// Signed 返回任务是否已签到，以及签到时提交的坐标
+func (s *Server) Signed(id int) (wisestu.SigninLocationPoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.signed[id]
+	return p, ok
+}
+
+// Photo 返回拍照签到时上传的照片
+func (s *Server) Photo(id int) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.photos[id]
+	return p, ok
+}
+
+// Answer 返回验证码的正确答案
+func (s *Server) Answer(verificationID string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	answer, ok := s.captchas[verificationID]
+	return answer, ok
+}
+
+// AnswerImage 返回验证码图片（base64 编码）对应的正确答案，可供模拟的 LLM 服务识别验证码
+func (s *Server) AnswerImage(image string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	answer, ok := s.images[image]
+	return answer, ok
+}
+
+// response 是模拟服务的通用响应结构
+type response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+// ServeHTTP 按路径和请求体中的 action 分发请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, response{Code: CodeBadRequest, Message: "请求格式错误"})
+		return
+	}
+	action, _ := body["action"].(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions = append(s.actions, action)
+
+	switch r.URL.Path {
+	case "/dnui/api/user/loginout.api":
+		switch action {
+		case "queryVerificationQuestion":
+			s.verification(w)
+		case "loginStudent":
+			s.login(w, body)
+		default:
+			writeJSON(w, response{Code: CodeBadRequest, Message: "未知的 action: " + action})
+		}
+		return
+	case "/dnui/api/student/basic/stuInfo.api", "/dnui/api/student/signin/signin.api":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if !s.authorize(r.Header.Get("Authorization")) {
+		writeJSON(w, response{Code: CodeNeedLogin, Message: "Need Login."})
+		return
+	}
+
+	switch action {
+	case "queryMyStuInfo":
+		writeJSON(w, response{Code: CodeOK, Message: "ok", Result: s.opts.Student})
+	case "getUnSigninList":
+		tasks := make([]wisestu.SigninTask, 0, len(s.tasks))
+		for _, t := range s.tasks {
+			if _, ok := s.signed[t.ID]; !ok {
+				tasks = append(tasks, t)
+			}
+		}
+		writeJSON(w, response{Code: CodeOK, Message: "ok", Result: map[string]interface{}{"list": tasks, "total": len(tasks)}})
+	case "getSigninDetails":
+		s.details(w, body)
+	case "checkOutsideFlag":
+		lng, _ := body["lng"].(float64)
+		lat, _ := body["lat"].(float64)
+		flag := "0"
+		if !s.insideFence(lng, lat) {
+			flag = "1"
+		}
+		// 点击签到：进入签到后在范围内点击即完成签到
+		id, _ := body["id"].(float64)
+		if flag == "0" && s.entered[int(id)] && !s.opts.PhotoRequired {
+			s.signed[int(id)] = wisestu.SigninLocationPoint{Lng: lng, Lat: lat}
+		}
+		writeJSON(w, response{Code: CodeOK, Message: "ok", Result: map[string]string{"outside_flag": flag}})
+	case "updateLocationSignin":
+		s.updateLocation(w, body)
+	case "uploadSigninImage":
+		s.uploadImage(w, body)
+	case "updatePhotoSignin":
+		s.updatePhoto(w, body)
+	case "getSigninSuccess":
+		task, ok := s.task(body)
+		if !ok {
+			writeJSON(w, response{Code: CodeNotFound, Message: "签到任务不存在"})
+			return
+		}
+		if _, ok := s.signed[task.ID]; !ok {
+			writeJSON(w, response{Code: CodeNotFound, Message: "尚未签到"})
+			return
+		}
+		writeJSON(w, response{Code: CodeOK, Message: "签到成功"})
+	default:
+		writeJSON(w, response{Code: CodeBadRequest, Message: "未知的 action: " + action})
+	}
+}
+
+func (s *Server) verification(w http.ResponseWriter) {
+	s.seq++
+	id := "mock-verification-" + strconv.Itoa(s.seq)
+	expr, answer := newExpression(s.rng)
+	image := renderExpression(expr)
+	s.captchas[id] = answer
+	s.images[image] = answer
+
+	// 与学校服务器一致，验证码字段直接位于响应顶层
+	writeJSON(w, map[string]interface{}{
+		"code":               CodeOK,
+		"message":            "ok",
+		"verification_id":    id,
+		"verification_image": image,
+	})
+}
+
+func (s *Server) login(w http.ResponseWriter, body map[string]interface{}) {
+	s.logins++
+	id, _ := body["verification_id"].(string)
+	answer, ok := s.captchas[id]
+	delete(s.captchas, id) // 每个验证码只能使用一次
+
+	given, err := strconv.Atoi(fmt.Sprint(body["verification_answer"]))
+	if s.logins <= s.opts.CaptchaFailures || !ok || err != nil || given != answer {
+		writeJSON(w, response{Code: CodeWrongCaptcha, Message: "验证码错误"})
+		return
+	}
+
+	if body["login_name"] != s.opts.Username || body["password"] != s.opts.Password {
+		writeJSON(w, response{Code: CodeWrongPassword, Message: "用户名或密码错误"})
+		return
+	}
+
+	if !s.opts.OmitToken {
+		s.seq++
+		token := "mock-token-" + strconv.Itoa(s.seq)
+		s.tokens[token] = -1
+		if s.opts.SessionRequests > 0 {
+			s.tokens[token] = s.opts.SessionRequests
+		}
+		w.Header().Set("token", token)
+	}
+	writeJSON(w, response{Code: CodeOK, Message: "登录成功"})
+}
+
+// authorize 检查 token 是否有效，并扣减其剩余可用次数
+func (s *Server) authorize(token string) bool {
+	left, ok := s.tokens[token]
+	if !ok || left == 0 {
+		return false
+	}
+	if left > 0 {
+		s.tokens[token] = left - 1
+	}
+	return true
+}
+
+func (s *Server) task(body map[string]interface{}) (wisestu.SigninTask, bool) {
+	id, _ := body["id"].(float64)
+	batchNo, _ := body["batch_no"].(float64)
+	for _, t := range s.tasks {
+		if t.ID == int(id) && t.BatchNo == int(batchNo) {
+			return t, true
+		}
+	}
+	return wisestu.SigninTask{}, false
+}
+
+func (s *Server) details(w http.ResponseWriter, body map[string]interface{}) {
+	task, ok := s.task(body)
+	if !ok {
+		writeJSON(w, response{Code: CodeNotFound, Message: "签到任务不存在"})
+		return
+	}
+	s.entered[task.ID] = true
+
+	// 除 id、batch_no 和 signin_type_name 外的字段名与 wisestu.SigninDetails 一样是推测的
+	result := map[string]interface{}{
+		"id":               task.ID,
+		"batch_no":         task.BatchNo,
+		"signin_type_name": task.SigninTypeName,
+	}
+	if f := s.opts.Fence; f != nil {
+		result["lng"], result["lat"], result["radius"] = f.Lng, f.Lat, f.Radius
+	}
+	result["need_photo"] = "0"
+	if s.opts.PhotoRequired {
+		result["need_photo"] = "1"
+	}
+	result["start_time"], result["end_time"] = wisestu.Time{Time: s.opts.Opens}, wisestu.Time{Time: s.opts.Closes}
+	writeJSON(w, response{Code: CodeOK, Message: "ok", Result: result})
+}
+
+func (s *Server) updateLocation(w http.ResponseWriter, body map[string]interface{}) {
+	task, point, ok := s.checkSubmit(w, body)
+	if !ok {
+		return
+	}
+	if s.opts.PhotoRequired {
+		writeJSON(w, response{Code: CodePhotoRequired, Message: "该签到需要拍照"})
+		return
+	}
+
+	s.signed[task.ID] = point
+	writeJSON(w, response{Code: CodeOK, Message: "签到成功"})
+}
+
+func (s *Server) uploadImage(w http.ResponseWriter, body map[string]interface{}) {
+	if _, ok := s.task(body); !ok {
+		writeJSON(w, response{Code: CodeNotFound, Message: "签到任务不存在"})
+		return
+	}
+	raw, _ := body["image"].(string)
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(data) == 0 {
+		writeJSON(w, response{Code: CodeBadRequest, Message: "图片格式错误"})
+		return
+	}
+
+	s.seq++
+	url := "/mock/photos/" + strconv.Itoa(s.seq) + path.Ext(fmt.Sprint(body["file_name"]))
+	s.uploads[url] = data
+	writeJSON(w, response{Code: CodeOK, Message: "ok", Result: map[string]string{"url": url}})
+}
+
+func (s *Server) updatePhoto(w http.ResponseWriter, body map[string]interface{}) {
+	task, point, ok := s.checkSubmit(w, body)
+	if !ok {
+		return
+	}
+	url, _ := body["photo_url"].(string)
+	photo, ok := s.uploads[url]
+	if !ok {
+		writeJSON(w, response{Code: CodeBadRequest, Message: "请先上传照片"})
+		return
+	}
+
+	s.signed[task.ID] = point
+	s.photos[task.ID] = photo
+	writeJSON(w, response{Code: CodeOK, Message: "签到成功"})
+}
+
+// checkSubmit 校验提交签到的任务、签到时间和位置，不通过时写入错误响应
+func (s *Server) checkSubmit(w http.ResponseWriter, body map[string]interface{}) (wisestu.SigninTask, wisestu.SigninLocationPoint, bool) {
+	task, ok := s.task(body)
+	if !ok {
+		writeJSON(w, response{Code: CodeNotFound, Message: "签到任务不存在"})
+		return task, wisestu.SigninLocationPoint{}, false
+	}
+	if !s.entered[task.ID] {
+		writeJSON(w, response{Code: CodeBadRequest, Message: "请先进入签到"})
+		return task, wisestu.SigninLocationPoint{}, false
+	}
+
+	raw, _ := body["signin_location"].(string)
+	var loc wisestu.SigninLocation
+	if err := json.Unmarshal([]byte(raw), &loc); err != nil {
+		writeJSON(w, response{Code: CodeBadRequest, Message: "签到位置格式错误"})
+		return task, wisestu.SigninLocationPoint{}, false
+	}
+	now := time.Now()
+	if (!s.opts.Opens.IsZero() && now.Before(s.opts.Opens)) || (!s.opts.Closes.IsZero() && now.After(s.opts.Closes)) {
+		writeJSON(w, response{Code: CodeNotInWindow, Message: "不在签到时间内"})
+		return task, wisestu.SigninLocationPoint{}, false
+	}
+	if !s.insideFence(loc.Point.Lng, loc.Point.Lat) {
+		writeJSON(w, response{Code: CodeOutsideFence, Message: "当前位置不在签到范围内"})
+		return task, wisestu.SigninLocationPoint{}, false
+	}
+	return task, loc.Point, true
+}
+
+func (s *Server) insideFence(lng, lat float64) bool {
+	f := s.opts.Fence
+	return f == nil || wisestu.Distance(f.Lng, f.Lat, lng, lat) <= f.Radius
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}